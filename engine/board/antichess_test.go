@@ -0,0 +1,126 @@
+package board
+
+import "testing"
+
+func TestAntichessPerft(t *testing.T) {
+	SetupTables()
+	board, err := ParseVariantFEN(StartFEN, Antichess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compulsory captures start cutting moves at depth 3
+	for depth, expected := range []int{20, 400, 8067, 153299} {
+		if actual := board.perft(depth + 1); actual != expected {
+			t.Errorf("Depth %d counted %d nodes instead of %d", depth+1, actual, expected)
+		}
+	}
+	if fen := board.FEN(); fen != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1" {
+		t.Errorf("Castling rights kept as %s", fen)
+	}
+}
+
+func TestCompulsoryCaptures(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		expected []string
+	}{
+		{"Only capture", "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", []string{"e4d5"}},
+		// The king walks into "check" and can be taken like anything else
+		{"King capture", "8/8/8/8/8/8/1q6/K7 w - - 0 1", []string{"a1b2"}},
+		{"Pinned capture", "4r3/8/8/8/8/5p2/4B3/4K3 w - - 0 1", []string{"e2f3"}},
+		{"Capturing promotions", "1n6/P7/8/8/8/8/8/8 w - - 0 1",
+			[]string{"a7b8n", "a7b8b", "a7b8r", "a7b8q", "a7b8k"}},
+	}
+	for _, tt := range tests {
+		board, err := ParseVariantFEN(tt.fen, Antichess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var moves MoveList
+		board.GenLegalMoves(&moves, false)
+		generated := make(map[string]bool)
+		for _, move := range moves.Slice() {
+			generated[move.String()] = true
+		}
+		for _, expected := range tt.expected {
+			if !generated[expected] {
+				t.Errorf("%s: %s wasn't generated", tt.name, expected)
+			}
+		}
+		if moves.Count != len(tt.expected) {
+			t.Errorf("%s: generated %v instead of %v", tt.name, moves.Slice(), tt.expected)
+		}
+		var quiets MoveList
+		if board.GenQuietMoves(&quiets); quiets.Count > 0 {
+			t.Errorf("%s: quiet moves %v generated alongside captures", tt.name, quiets.Slice())
+		}
+	}
+
+	board, _ := ParseVariantFEN("4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", Antichess)
+	if board.IsLegal(NewMove(E4, E5, NoFlag)) {
+		t.Errorf("Pushing a pawn is legal when it could capture instead")
+	}
+	if !board.IsLegal(NewMove(E4, D5, Capture)) {
+		t.Errorf("The only capture isn't legal")
+	}
+}
+
+func TestKingPromotion(t *testing.T) {
+	SetupTables()
+	board, _ := ParseVariantFEN("8/4P3/8/8/8/8/8/k7 w - - 0 1", Antichess)
+	promotion := NewKingPromotion(E7, E8, NoFlag)
+	if promotion.String() != "e7e8k" {
+		t.Errorf("King promotion written for UCI as %s", promotion)
+	}
+	if san := board.MoveToSAN(promotion); san != "e8=K" {
+		t.Errorf("King promotion written in SAN as %s", san)
+	}
+	if move, err := board.ParseSAN("e8=K"); err != nil || move != promotion {
+		t.Errorf("e8=K parsed as %s (%v)", move, err)
+	}
+
+	hash := board.Hash()
+	board.UCIMakeMove("e7e8k")
+	if fen := board.FEN(); fen != "4K3/8/8/8/8/8/8/k7 b - - 0 1" {
+		t.Errorf("Promoting to a king gave %s", fen)
+	}
+	board.UndoMove(promotion)
+	if fen := board.FEN(); fen != "8/4P3/8/8/8/8/8/k7 w - - 0 1" || board.Hash() != hash {
+		t.Errorf("Undoing the promotion gave %s", fen)
+	}
+
+	// Only Antichess has them
+	standard := FromFEN("4k3/P7/8/8/8/8/8/4K3 w - - 0 1")
+	if standard.IsLegal(NewKingPromotion(A7, A8, NoFlag)) {
+		t.Errorf("King promotion is legal in standard chess")
+	}
+}
+
+func TestAntichessStatus(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		expected Status
+	}{
+		{"Out of pieces", "8/8/8/8/8/8/8/k7 w - - 0 1", OutOfPieces},
+		{"Blocked in", "8/8/8/8/8/p7/K7/8 b - - 0 1", OutOfMoves},
+		// Kings don't count for anything
+		{"Bare kings", "8/8/8/3k4/8/8/8/4K3 w - - 0 1", Ongoing},
+	}
+	for _, tt := range tests {
+		board, err := ParseVariantFEN(tt.fen, Antichess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status := board.Status()
+		if status != tt.expected {
+			t.Errorf("%s: status is %s instead of %s", tt.name, status, tt.expected)
+		}
+		if status != Ongoing && (!status.IsWin() || status.IsLoss() || status.IsDraw()) {
+			t.Errorf("%s: %s doesn't count as a win", tt.name, status)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package board
+
+// Attack information for one position, reused until the board changes
+// Keyed on the hash and occupancy so it never outlives the position
+type attackCache struct {
+	hash     uint64
+	occupied Bitboard
+
+	hasPinned    [2]bool
+	pinned       [2]Bitboard
+	hasAttackMap [2]bool
+	attackMap    [2]Bitboard
+}
+
+func (board *Board) attackCache() *attackCache {
+	occupied := board.colorBitboards[White] | board.colorBitboards[Black]
+	if board.attacks.hash != board.hash || board.attacks.occupied != occupied {
+		board.attacks = attackCache{hash: board.hash, occupied: occupied}
+	}
+	return &board.attacks
+}
+
+// Pieces of the given color attacking a square
+func (board *Board) AttackersTo(sq Square, color int) Bitboard {
+	occupied := board.colorBitboards[White] | board.colorBitboards[Black]
+	return board.attackersTo(sq, occupied) & board.colorBitboards[color]
+}
+
+// Every piece of either color attacking a square, with the given pieces on the board
+func (board *Board) attackersTo(sq Square, occupied Bitboard) Bitboard {
+	whitePawns := board.pieceBitboards[Pawn] & board.colorBitboards[White]
+	blackPawns := board.pieceBitboards[Pawn] & board.colorBitboards[Black]
+	orthoPieces := board.pieceBitboards[Rook] | board.pieceBitboards[Queen]
+	diagPieces := board.pieceBitboards[Bishop] | board.pieceBitboards[Queen]
+
+	return PawnAttacks[Black][sq]&whitePawns |
+		PawnAttacks[White][sq]&blackPawns |
+		KnightAttacks[sq]&board.pieceBitboards[Knight] |
+		KingAttacks[sq]&board.pieceBitboards[King] |
+		rookAttackBitboard(sq, occupied)&orthoPieces |
+		bishopAttackBitboard(sq, occupied)&diagPieces
+}
+
+// Pieces giving check to the side to move
+func (board *Board) Checkers() Bitboard {
+	kingSq, hasKing := board.KingSquare(board.whoseTurn)
+	if !hasKing {
+		return 0
+	}
+	return board.AttackersTo(kingSq, (board.whoseTurn+1)%2)
+}
+
+// Pieces of the given color that can't leave the line between
+// their king and an enemy slider
+func (board *Board) Pinned(color int) Bitboard {
+	cache := board.attackCache()
+	if !cache.hasPinned[color] {
+		cache.pinned[color] = board.pinnedPieces(color)
+		cache.hasPinned[color] = true
+	}
+	return cache.pinned[color]
+}
+
+func (board *Board) pinnedPieces(color int) Bitboard {
+	kingSq, hasKing := board.KingSquare(color)
+	if !hasKing {
+		return 0
+	}
+	friendlyBitboard := board.colorBitboards[color]
+	enemyBitboard := board.colorBitboards[(color+1)%2]
+	allPieces := friendlyBitboard | enemyBitboard
+
+	// Enemy sliders that would be attacking the king on an empty board
+	snipers := rookAttackBitboard(kingSq, 0) & enemyBitboard &
+		(board.pieceBitboards[Rook] | board.pieceBitboards[Queen])
+	snipers |= bishopAttackBitboard(kingSq, 0) & enemyBitboard &
+		(board.pieceBitboards[Bishop] | board.pieceBitboards[Queen])
+
+	pinned := Bitboard(0)
+	for snipers > 0 {
+		sniperSq := snipers.PopLSB()
+		blockers := BetweenMasks[kingSq][sniperSq] & allPieces
+		// Exactly one piece in the way, and it's ours
+		if blockers > 0 && blockers&(blockers-1) == 0 {
+			pinned |= blockers & friendlyBitboard
+		}
+	}
+	return pinned
+}
+
+// Every square the given color attacks, whether or not there's
+// anything on it to capture
+func (board *Board) AttackMap(color int) Bitboard {
+	cache := board.attackCache()
+	if !cache.hasAttackMap[color] {
+		cache.attackMap[color] = board.genAttackMap(color)
+		cache.hasAttackMap[color] = true
+	}
+	return cache.attackMap[color]
+}
+
+func (board *Board) genAttackMap(color int) Bitboard {
+	friendlyBitboard := board.colorBitboards[color]
+	occupied := board.colorBitboards[White] | board.colorBitboards[Black]
+
+	attacks := Bitboard(0)
+	for piece := Pawn; piece <= King; piece++ {
+		pieces := board.pieceBitboards[piece] & friendlyBitboard
+		for pieces > 0 {
+			sq := pieces.PopLSB()
+			switch piece {
+			case Pawn:
+				attacks |= PawnAttacks[color][sq]
+			case Knight:
+				attacks |= KnightAttacks[sq]
+			case Bishop:
+				attacks |= bishopAttackBitboard(sq, occupied)
+			case Rook:
+				attacks |= rookAttackBitboard(sq, occupied)
+			case Queen:
+				attacks |= bishopAttackBitboard(sq, occupied) |
+					rookAttackBitboard(sq, occupied)
+			case King:
+				attacks |= KingAttacks[sq]
+			}
+		}
+	}
+	return attacks
+}
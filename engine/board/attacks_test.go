@@ -0,0 +1,102 @@
+package board
+
+import (
+	"testing"
+
+	"20hh/engine/util"
+)
+
+func bitboardOf(squares ...Square) Bitboard {
+	bb := Bitboard(0)
+	for _, sq := range squares {
+		bb.SetSquare(sq)
+	}
+	return bb
+}
+
+func TestAttackersTo(t *testing.T) {
+	SetupTables()
+	board := FromFEN("3q1k2/3r4/8/2n5/3p4/4P3/3R4/3RK3 w - - 0 1")
+	// The rook on d1 is behind d2, so it only counts once d2 is gone
+	if attackers := board.AttackersTo(D4, White); attackers != bitboardOf(D2, E3) {
+		t.Errorf("White attackers of d4 were\n%s", attackers)
+	}
+	if attackers := board.AttackersTo(D4, Black); attackers != bitboardOf(D7) {
+		t.Errorf("Black attackers of d4 were\n%s", attackers)
+	}
+	if attackers := board.AttackersTo(E3, Black); attackers != bitboardOf(D4) {
+		t.Errorf("Black attackers of e3 were\n%s", attackers)
+	}
+}
+
+func TestPinnedAndCheckers(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		pinned   Bitboard // Side to move's pinned pieces
+		checkers Bitboard
+	}{
+		{"Starting pos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0, 0},
+		{"Pins on two lines", "4r3/8/8/b7/8/2N5/4R3/4K3 w - - 0 1", bitboardOf(C3, E2), 0},
+		{"Two blockers isn't a pin", "4r3/8/8/8/4n3/8/4R3/4K3 w - - 0 1", 0, 0},
+		{"Enemy blocker isn't a pin", "4r3/8/8/8/4n3/8/8/4K3 w - - 0 1", 0, 0},
+		{"Single check", "4k3/8/8/8/8/5n2/8/4K3 w - - 0 1", 0, bitboardOf(F3)},
+		{"Double check", "4k3/8/8/8/4r3/5n2/8/4K3 w - - 0 1", 0, bitboardOf(F3, E4)},
+		{"Pinned while in check", "4k3/8/8/b7/8/2B2n2/8/4K3 w - - 0 1", bitboardOf(C3), bitboardOf(F3)},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		if pinned := board.Pinned(White); pinned != tt.pinned {
+			t.Errorf("%s: pinned pieces were\n%s", tt.name, pinned)
+		}
+		if checkers := board.Checkers(); checkers != tt.checkers {
+			t.Errorf("%s: checkers were\n%s", tt.name, checkers)
+		}
+	}
+}
+
+// Checks the cached attack maps against AttackersTo square by square
+// while playing random games, so stale cache entries would show up
+func TestAttackMap(t *testing.T) {
+	SetupTables()
+	util.RandInit(0x2b1c9a8d7e6f5a4b)
+	for _, position := range perftPositions {
+		board := FromFEN(position.fen)
+		var played []Move
+		for ply := 0; ply < 40; ply++ {
+			for color := White; color <= Black; color++ {
+				expected := Bitboard(0)
+				for sq := Square(0); sq < 64; sq++ {
+					if board.AttackersTo(sq, color) > 0 {
+						expected.SetSquare(sq)
+					}
+				}
+				if attacks := board.AttackMap(color); attacks != expected {
+					t.Fatalf("%s: attack map for %d was\n%s\ninstead of\n%s",
+						board.FEN(), color, attacks, expected)
+				}
+			}
+
+			move, ok := playRandomMove(&board)
+			if !ok {
+				break
+			}
+			played = append(played, move)
+		}
+
+		// The cache has to follow the board back too
+		for i := len(played) - 1; i >= 0; i-- {
+			board.UndoMove(played[i])
+		}
+		fresh := FromFEN(position.fen)
+		for color := White; color <= Black; color++ {
+			if board.AttackMap(color) != fresh.AttackMap(color) {
+				t.Errorf("%s: attack map changed after undoing moves", position.name)
+			}
+			if board.Pinned(color) != fresh.Pinned(color) {
+				t.Errorf("%s: pinned pieces changed after undoing moves", position.name)
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package board
 
 import (
 	"fmt"
-	"math"
 	"math/bits"
 )
 
@@ -31,6 +30,42 @@ func (bb *Bitboard) PopLSB() uint8 {
 	return n
 }
 
+// The lowest set square, without clearing it
+func (bb Bitboard) LSB() Square {
+	return Square(bits.TrailingZeros64(uint64(bb)))
+}
+
+func (bb Bitboard) PopCount() int {
+	return bits.OnesCount64(uint64(bb))
+}
+
+// Walks the set squares lowest first without allocating or touching bb:
+//
+//	for it := bb.Iter(); it.Next(); {
+//		sq := it.Square()
+//	}
+type SquareIter struct {
+	remaining Bitboard
+	sq        Square
+}
+
+func (bb Bitboard) Iter() SquareIter {
+	return SquareIter{remaining: bb}
+}
+
+// Moves on to the next square, returning false once there aren't any left
+func (it *SquareIter) Next() bool {
+	if it.remaining == 0 {
+		return false
+	}
+	it.sq = it.remaining.PopLSB()
+	return true
+}
+
+func (it *SquareIter) Square() Square {
+	return it.sq
+}
+
 func (bb Bitboard) RShift(amt uint8) Bitboard {
 	return bb >> Bitboard(amt)
 }
@@ -42,11 +77,10 @@ func (bb Bitboard) LShift(amt uint8) Bitboard {
 // When passed a negative number, shifts right instead of left
 // Nice when generating bishop / rook move masks
 func (bb Bitboard) ShiftWithNegative(amt int16) Bitboard {
-	shiftAmt := uint8(math.Abs(float64(amt)))
 	if amt < 0 {
-		return bb >> Bitboard(shiftAmt)
+		return bb >> Bitboard(-amt)
 	} else {
-		return bb << Bitboard(shiftAmt)
+		return bb << Bitboard(amt)
 	}
 }
 
@@ -69,3 +103,118 @@ func (bb Bitboard) String() string {
 	out += "   A  B  C  D  E  F  G  H "
 	return out
 }
+
+const (
+	FileABB = Bitboard(0x0101010101010101)
+	FileHBB = FileABB << 7
+	Rank1BB = Bitboard(0xFF)
+	Rank8BB = Rank1BB << 56
+)
+
+// One of the eight ways a piece can move, with north towards black's side
+type Direction uint8
+
+const (
+	DirNorth = Direction(iota)
+	DirNorthEast
+	DirEast
+	DirSouthEast
+	DirSouth
+	DirSouthWest
+	DirWest
+	DirNorthWest
+)
+
+var Directions = [...]Direction{
+	DirNorth, DirNorthEast, DirEast, DirSouthEast,
+	DirSouth, DirSouthWest, DirWest, DirNorthWest,
+}
+
+// How far a square index moves in each direction
+var directionOffsets = [...]int16{8, 9, 1, -7, -8, -9, -1, 7}
+
+// Squares that can't be reached by a shift in each direction without
+// wrapping around to the other side of the board
+var wrapMasks = [...]Bitboard{
+	^Bitboard(0), ^FileABB, ^FileABB, ^FileABB,
+	^Bitboard(0), ^FileHBB, ^FileHBB, ^FileHBB,
+}
+
+// Moves every square one step in a direction, dropping the ones
+// that would go off the edge of the board
+func (bb Bitboard) Shift(dir Direction) Bitboard {
+	return bb.ShiftWithNegative(directionOffsets[dir]) & wrapMasks[dir]
+}
+
+func (bb Bitboard) North() Bitboard     { return bb << 8 }
+func (bb Bitboard) South() Bitboard     { return bb >> 8 }
+func (bb Bitboard) East() Bitboard      { return bb << 1 &^ FileABB }
+func (bb Bitboard) West() Bitboard      { return bb >> 1 &^ FileHBB }
+func (bb Bitboard) NorthEast() Bitboard { return bb << 9 &^ FileABB }
+func (bb Bitboard) NorthWest() Bitboard { return bb << 7 &^ FileHBB }
+func (bb Bitboard) SouthEast() Bitboard { return bb >> 7 &^ FileABB }
+func (bb Bitboard) SouthWest() Bitboard { return bb >> 9 &^ FileHBB }
+
+// Every square on or in front of the set ones, from white's side
+func (bb Bitboard) NorthFill() Bitboard {
+	bb |= bb << 8
+	bb |= bb << 16
+	bb |= bb << 32
+	return bb
+}
+
+// Every square on or in front of the set ones, from black's side
+func (bb Bitboard) SouthFill() Bitboard {
+	bb |= bb >> 8
+	bb |= bb >> 16
+	bb |= bb >> 32
+	return bb
+}
+
+// Every file with a set square on it
+func (bb Bitboard) FileFill() Bitboard {
+	return bb.NorthFill() | bb.SouthFill()
+}
+
+// Squares strictly in front of the set ones, not counting the squares themselves
+func (bb Bitboard) NorthSpan() Bitboard {
+	return bb.NorthFill().North()
+}
+
+func (bb Bitboard) SouthSpan() Bitboard {
+	return bb.SouthFill().South()
+}
+
+// The span in the direction a color's pawns move
+func (bb Bitboard) ForwardSpan(color int) Bitboard {
+	if color == Black {
+		return bb.SouthSpan()
+	}
+	return bb.NorthSpan()
+}
+
+// The files on either side of the ones with set squares, which is
+// where pawns that could support or stop them have to be
+func (bb Bitboard) AdjacentFiles() Bitboard {
+	files := bb.FileFill()
+	return files.East() | files.West()
+}
+
+// Kogge-Stone fill: spreads the set squares in a direction until they reach
+// a square that isn't empty, without going onto it
+func (bb Bitboard) OccludedFill(dir Direction, empty Bitboard) Bitboard {
+	offset := directionOffsets[dir]
+	empty &= wrapMasks[dir]
+	bb |= empty & bb.ShiftWithNegative(offset)
+	empty &= empty.ShiftWithNegative(offset)
+	bb |= empty & bb.ShiftWithNegative(2*offset)
+	empty &= empty.ShiftWithNegative(2 * offset)
+	bb |= empty & bb.ShiftWithNegative(4*offset)
+	return bb
+}
+
+// Every square the set squares would attack sliding in a direction,
+// including the first blocker
+func (bb Bitboard) SlidingAttacks(dir Direction, empty Bitboard) Bitboard {
+	return bb.OccludedFill(dir, empty).Shift(dir)
+}
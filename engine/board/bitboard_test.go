@@ -0,0 +1,135 @@
+package board
+
+import (
+	"testing"
+
+	"20hh/engine/util"
+)
+
+func TestPopCount(t *testing.T) {
+	util.RandInit(0x510e527fade682d1)
+	for i := 0; i < 1000; i++ {
+		bb := Bitboard(util.RandU64())
+		expected := 0
+		for sq := Square(0); sq < 64; sq++ {
+			if bb.QuerySquare(sq) {
+				expected++
+			}
+		}
+		if count := bb.PopCount(); count != expected {
+			t.Fatalf("0x%x counted %d bits instead of %d", uint64(bb), count, expected)
+		}
+	}
+}
+
+func TestIter(t *testing.T) {
+	bb := Bitboard(1<<A1 | 1<<E4 | 1<<H8)
+	var squares []Square
+	for it := bb.Iter(); it.Next(); {
+		squares = append(squares, it.Square())
+	}
+	if len(squares) != 3 || squares[0] != A1 || squares[1] != E4 || squares[2] != H8 {
+		t.Errorf("Iterated over %v", squares)
+	}
+	if bb != Bitboard(1<<A1|1<<E4|1<<H8) {
+		t.Errorf("Iterating changed the bitboard")
+	}
+	if bb.LSB() != A1 {
+		t.Errorf("LSB is %d", bb.LSB())
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		total := 0
+		for it := Bitboard(0xFFFF00000000FFFF).Iter(); it.Next(); {
+			total += int(it.Square())
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("Iterating allocated %.0f times", allocs)
+	}
+}
+
+// Every shift should match moving one rank and/or file by hand
+func TestShift(t *testing.T) {
+	rankSteps := [...]int{1, 1, 0, -1, -1, -1, 0, 1}
+	fileSteps := [...]int{0, 1, 1, 1, 0, -1, -1, -1}
+	named := [...]func(Bitboard) Bitboard{
+		Bitboard.North, Bitboard.NorthEast, Bitboard.East, Bitboard.SouthEast,
+		Bitboard.South, Bitboard.SouthWest, Bitboard.West, Bitboard.NorthWest,
+	}
+	for _, dir := range Directions {
+		for sq := Square(0); sq < 64; sq++ {
+			rank := int(sq/8) + rankSteps[dir]
+			file := int(sq%8) + fileSteps[dir]
+			expected := Bitboard(0)
+			if rank >= 0 && rank < 8 && file >= 0 && file < 8 {
+				expected.SetSquare(ConvertRankFile(uint8(rank), uint8(file)))
+			}
+			bb := Bitboard(1) << sq
+			if shifted := bb.Shift(dir); shifted != expected {
+				t.Fatalf("Direction %d from %s gave 0x%x", dir, squareName(sq), uint64(shifted))
+			}
+			if shifted := named[dir](bb); shifted != expected {
+				t.Fatalf("Named shift %d from %s gave 0x%x", dir, squareName(sq), uint64(shifted))
+			}
+		}
+	}
+}
+
+func TestFillsAndSpans(t *testing.T) {
+	e4 := Bitboard(1 << E4)
+	eFile := FileABB << E
+	var tests = []struct {
+		name     string
+		actual   Bitboard
+		expected Bitboard
+	}{
+		{"North fill", e4.NorthFill(), eFile &^ (1<<E1 | 1<<E2 | 1<<E3)},
+		{"South fill", e4.SouthFill(), 1<<E1 | 1<<E2 | 1<<E3 | 1<<E4},
+		{"File fill", e4.FileFill(), eFile},
+		{"North span", e4.NorthSpan(), 1<<E5 | 1<<E6 | 1<<E7 | 1<<E8},
+		{"South span", e4.SouthSpan(), 1<<E1 | 1<<E2 | 1<<E3},
+		{"Black's forward span", e4.ForwardSpan(Black), e4.SouthSpan()},
+		{"Adjacent files", e4.AdjacentFiles(), FileABB<<D | FileABB<<F},
+		{"Adjacent to the edge", Bitboard(1 << A7).AdjacentFiles(), FileABB << B},
+		{"Rank constants", Rank1BB | Rank8BB, backRanks},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("%s gave\n%s\ninstead of\n%s", tt.name, tt.actual, tt.expected)
+		}
+	}
+}
+
+// Kogge-Stone sliding attacks should agree with the magic lookups
+func TestSlidingAttacks(t *testing.T) {
+	Init()
+	util.RandInit(0x9b05688c2b3e6c1f)
+	for i := 0; i < 2000; i++ {
+		occupied := Bitboard(util.RandU64() & util.RandU64())
+		sq := Square(util.RandU64() % 64)
+		bb := Bitboard(1) << sq
+
+		var rook, bishop Bitboard
+		for _, dir := range Directions {
+			attacks := bb.SlidingAttacks(dir, ^occupied)
+			if dir%2 == 0 {
+				rook |= attacks
+			} else {
+				bishop |= attacks
+			}
+		}
+		if expected := rookAttackBitboard(sq, occupied); rook != expected {
+			t.Fatalf("Rook on %s gave\n%s\ninstead of\n%s", squareName(sq), rook, expected)
+		}
+		if expected := bishopAttackBitboard(sq, occupied); bishop != expected {
+			t.Fatalf("Bishop on %s gave\n%s\ninstead of\n%s", squareName(sq), bishop, expected)
+		}
+	}
+
+	// The fill itself stops short of the blocker
+	fill := Bitboard(1<<A1).OccludedFill(DirNorth, ^Bitboard(1<<A4))
+	if fill != 1<<A1|1<<A2|1<<A3 {
+		t.Errorf("Occluded fill gave\n%s", fill)
+	}
+}
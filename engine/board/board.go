@@ -1,9 +1,6 @@
 package board
 
 import (
-	"fmt"
-	"strings"
-
 	"20hh/engine/util/collections"
 )
 
@@ -42,6 +39,17 @@ type Board struct {
 	whoseTurn int
 
 	castleRights uint8
+	// Where the rook for each castle right starts, in the same order as the
+	// rights bits (K, Q, k, q). Only differs from the corners in Chess960
+	castleRookSqs [4]Square
+	chess960      bool
+
+	variant Variant
+	// Checks each color has given, for Three-check
+	checksGiven [2]uint8
+	// Pieces in hand and pieces that were pawns, for Crazyhouse
+	pockets  pockets
+	promoted Bitboard
 
 	enPassantSq SquareOrNone
 
@@ -56,15 +64,29 @@ type Board struct {
 	capturedPieces collections.ArrayStack[Piece]
 	rollbacks      collections.ArrayStack[Rollback]
 
-	hash            uint64
-	positionHistory [150]uint64 // used for repetitions
+	hash uint64
+	// Only the pawns, for caching pawn structure evaluation
+	pawnHash uint64
+	// How many of each piece there are, wherever they are
+	materialHash uint64
+	// Hash of every position since the board was set up, used for repetitions
+	// The first entry is the position at historyStart half moves
+	positionHistory []uint64
+	historyStart    int
+	// The ply right after the last null move, since a repetition
+	// can't be claimed across a move that was never played
+	nullMovePly int
+
+	attacks attackCache
 }
 
 // Doesn't set actual board state, just initializes data structures
 func NewBoard() Board {
 	return Board{
-		capturedPieces: collections.NewArrayStack[Piece](30),
-		rollbacks:      collections.NewArrayStack[Rollback](100),
+		capturedPieces:  collections.NewArrayStack[Piece](30),
+		rollbacks:       collections.NewArrayStack[Rollback](100),
+		positionHistory: make([]uint64, 0, 100),
+		castleRookSqs:   [4]Square{H1, A1, H8, A8},
 	}
 }
 
@@ -72,8 +94,10 @@ func ConvertRankFile(rank, file uint8) Square {
 	return Square(rank*8 + file)
 }
 
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
 func StartPos() Board {
-	return FromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	return FromFEN(StartFEN)
 }
 
 type Rollback struct {
@@ -83,7 +107,13 @@ type Rollback struct {
 	checkMask     Bitboard
 	enPassantSq   SquareOrNone
 	halfMoveClock int
+	checksGiven   [2]uint8
+	pockets       pockets
+	promoted      Bitboard
 	hash          uint64
+	pawnHash      uint64
+	materialHash  uint64
+	nullMovePly   int
 }
 
 func (board Board) rollback() Rollback {
@@ -94,7 +124,13 @@ func (board Board) rollback() Rollback {
 		checkMask:     board.checkMask,
 		enPassantSq:   board.enPassantSq,
 		halfMoveClock: board.halfMoveClock,
+		checksGiven:   board.checksGiven,
+		pockets:       board.pockets,
+		promoted:      board.promoted,
 		hash:          board.hash,
+		pawnHash:      board.pawnHash,
+		materialHash:  board.materialHash,
+		nullMovePly:   board.nullMovePly,
 	}
 }
 
@@ -110,6 +146,16 @@ func (board *Board) PieceArray() *[64]Piece {
 	return &board.pieces
 }
 
+// Chess960 is turned on automatically for FENs that can only be Chess960,
+// but a standard looking start position needs it set explicitly
+func (board *Board) IsChess960() bool {
+	return board.chess960
+}
+
+func (board *Board) SetChess960(chess960 bool) {
+	board.chess960 = chess960
+}
+
 func (board *Board) WhiteToMove() bool {
 	return board.whoseTurn == White
 }
@@ -130,25 +176,42 @@ func (board *Board) Hash() uint64 {
 	return board.hash
 }
 
+func (board *Board) PawnHash() uint64 {
+	return board.pawnHash
+}
+
+func (board *Board) MaterialHash() uint64 {
+	return board.materialHash
+}
+
+// Returns 0 for plies that aren't stored in the history
 func (board *Board) PosAtNthPly(ply int) uint64 {
-	return board.positionHistory[ply]
+	idx := ply - board.historyStart
+	if idx < 0 || idx >= len(board.positionHistory) {
+		return 0
+	}
+	return board.positionHistory[idx]
+}
+
+// Records the current position as the one reached at this ply,
+// dropping anything left over from moves that were undone
+func (board *Board) recordPosition() {
+	idx := board.halfMoves - board.historyStart
+	board.positionHistory = append(board.positionHistory[:idx], board.hash)
+}
+
+// Deep copies the board, since plain copies share their
+// history and stacks and can't both have moves made on them
+func (board *Board) Clone() Board {
+	clone := *board
+	clone.capturedPieces = board.capturedPieces.Clone()
+	clone.rollbacks = board.rollbacks.Clone()
+	clone.positionHistory = make([]uint64, len(board.positionHistory), cap(board.positionHistory))
+	copy(clone.positionHistory, board.positionHistory)
+	return clone
 }
 
+// The plain ASCII grid, from white's side
 func (board *Board) String() string {
-	out := ""
-	for row := 7; row >= 0; row-- {
-		out += fmt.Sprintf("%d ", row+1)
-		for col := 0; col < 8; col++ {
-			idx := ConvertRankFile(uint8(row), uint8(col))
-			pieceAtIdx := board.pieces[idx]
-			val := string(" PNBRQK"[pieceAtIdx])
-			if board.colorBitboards[Black].QuerySquare(idx) {
-				val = strings.ToLower(val)
-			}
-			out += fmt.Sprintf("[%s]", val)
-		}
-		out += "\n"
-	}
-	out += "   A  B  C  D  E  F  G  H "
-	return out
+	return board.Render(RenderOptions{})
 }
@@ -0,0 +1,123 @@
+package board
+
+import "testing"
+
+// Shuffles knights back and forth through UCI, well past the
+// old fixed history size, checking repetitions are still seen
+func TestLongGame(t *testing.T) {
+	SetupTables()
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		// Starting deep into a game shouldn't matter either
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 400",
+	} {
+		board := FromFEN(fen)
+		startHash := board.Hash()
+		shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+		const cycles = 150
+		for i := 0; i < cycles; i++ {
+			for _, move := range shuffle {
+				board.UCIMakeMove(move)
+			}
+			if count := board.RepetitionCount(); count != i+2 {
+				t.Fatalf("%s: position counted %d times instead of %d after %d ply",
+					fen, count, i+2, board.HalfMoveClock())
+			}
+		}
+
+		if board.Hash() != startHash {
+			t.Errorf("%s: hash changed after %d ply", fen, cycles*len(shuffle))
+		}
+		if board.HalfMoveClock() != cycles*len(shuffle) {
+			t.Errorf("%s: halfmove clock is %d instead of %d",
+				fen, board.HalfMoveClock(), cycles*len(shuffle))
+		}
+		// Past a hundred half moves the fifty-move rule comes first
+		if status := board.Status(); status != FiftyMoveRule {
+			t.Errorf("%s: long game returned %s", fen, status)
+		}
+
+		// A pawn move resets things, and undoing it brings them back
+		board.UCIMakeMove("e2e4")
+		if board.IsRepetition() {
+			t.Errorf("%s: repetition found after a pawn move", fen)
+		}
+		board.UndoMove(NewMove(E2, E4, DblPawnMove))
+		if board.RepetitionCount() != cycles+1 {
+			t.Errorf("%s: undoing a move lost the history", fen)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	SetupTables()
+	board := StartPos()
+	board.UCIMakeMove("e2e4")
+	clone := board.Clone()
+
+	// Playing on the clone shouldn't touch the original's history
+	clone.UCIMakeMove("e7e5")
+	clone.UCIMakeMove("g1f3")
+	board.UCIMakeMove("c7c5")
+	board.UCIMakeMove("g1f3")
+
+	if fen := clone.FEN(); fen != "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2" {
+		t.Errorf("Clone ended up at %s", fen)
+	}
+	if fen := board.FEN(); fen != "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2" {
+		t.Errorf("Original ended up at %s", fen)
+	}
+	for ply := 0; ply <= 3; ply++ {
+		if ply <= 1 && clone.PosAtNthPly(ply) != board.PosAtNthPly(ply) {
+			t.Errorf("Shared history differs at ply %d", ply)
+		}
+		if ply > 1 && clone.PosAtNthPly(ply) == board.PosAtNthPly(ply) {
+			t.Errorf("Clone and original share history at ply %d", ply)
+		}
+	}
+}
+
+func TestNullMove(t *testing.T) {
+	SetupTables()
+	board := StartPos()
+	for _, move := range []string{"e2e4", "d7d5", "e4e5", "f7f5"} {
+		board.UCIMakeMove(move)
+	}
+	fen := board.FEN()
+	hash := board.Hash()
+
+	board.MakeNullMove()
+	nullFEN := "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR b KQkq - 1 3"
+	if got := board.FEN(); got != nullFEN {
+		t.Errorf("Null move gave %s", got)
+	}
+	if expected := FromFEN(nullFEN); board.Hash() != expected.Hash() {
+		t.Errorf("Null move hash is 0x%x instead of 0x%x", board.Hash(), expected.Hash())
+	}
+	if board.PosAtNthPly(board.TotalHalfMoves()) != board.Hash() {
+		t.Errorf("Null move position wasn't recorded")
+	}
+
+	// Passing back doesn't count as a repetition of the position before
+	board.MakeNullMove()
+	if board.IsRepetition() {
+		t.Errorf("Repetition found across a null move")
+	}
+	board.UndoNullMove()
+	board.UndoNullMove()
+
+	if got := board.FEN(); got != fen {
+		t.Errorf("Undoing null moves gave %s instead of %s", got, fen)
+	}
+	if board.Hash() != hash {
+		t.Errorf("Undoing null moves gave hash 0x%x instead of 0x%x", board.Hash(), hash)
+	}
+
+	// Repetitions work as usual after undoing
+	for _, move := range []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6"} {
+		board.UCIMakeMove(move)
+	}
+	if !board.IsRepetition() {
+		t.Errorf("Repetition missed after undoing null moves")
+	}
+}
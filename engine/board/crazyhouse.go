@@ -0,0 +1,159 @@
+package board
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Pieces captured in Crazyhouse, indexed by color then piece,
+// that can be dropped back onto the board as the capturer's own
+type pockets [2][King]uint8
+
+// No more than 16 pawns can ever be in one pocket
+const maxPocket = 16
+
+// Zobrist numbers for how many of each piece are in each pocket,
+// where an empty pocket hashes to 0 to keep other variants the same
+var pocketKeys = genPocketKeys()
+
+func genPocketKeys() (keys [2][King][maxPocket + 1]uint64) {
+	// splitmix64, so the numbers are the same every run
+	state := uint64(0x9e3779b97f4a7c15)
+	for color := range keys {
+		for piece := Pawn; piece < King; piece++ {
+			for count := 1; count <= maxPocket; count++ {
+				state += 0x9e3779b97f4a7c15
+				z := state
+				z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+				z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+				keys[color][piece][count] = z ^ (z >> 31)
+			}
+		}
+	}
+	return keys
+}
+
+// How many of a piece a color has in hand
+func (board *Board) InPocket(color int, piece Piece) int {
+	if piece == EmptySquare || piece >= King {
+		return 0
+	}
+	return int(board.pockets[color][piece])
+}
+
+// Changes the count of a piece in a pocket, keeping the hash up to date
+func (board *Board) addToPocket(color int, piece Piece, amount int) {
+	count := &board.pockets[color][piece]
+	board.hash ^= pocketKeys[color][piece][*count]
+	*count = uint8(int(*count) + amount)
+	board.hash ^= pocketKeys[color][piece][*count]
+}
+
+// Puts captured pieces in the capturer's pocket and keeps track of which
+// pieces came from promotions, since they go back to being pawns when taken
+// Called before the move is made on the board
+func (board *Board) updatePockets(move Move, capturedPiece Piece) {
+	from := move.GetFrom()
+	to := move.GetTo()
+
+	if capturedPiece != EmptySquare {
+		if board.promoted.QuerySquare(to) {
+			capturedPiece = Pawn
+		}
+		board.addToPocket(board.whoseTurn, capturedPiece, 1)
+		board.promoted.ClearSquare(to)
+	} else if move.GetFlag() == EnPassant {
+		board.addToPocket(board.whoseTurn, Pawn, 1)
+	}
+
+	if board.promoted.QuerySquare(from) || move.HasFlag(Promotion) {
+		board.promoted.ClearSquare(from)
+		board.promoted.SetSquare(to)
+	}
+}
+
+// Puts a piece from the pocket on the board
+func (board *Board) dropPiece(move Move) {
+	piece := move.DropPiece()
+	to := move.GetTo()
+	board.addToPocket(board.whoseTurn, piece, -1)
+	board.pieces[to] = piece
+	board.pieceBitboards[piece].SetSquare(to)
+	board.colorBitboards[board.whoseTurn].SetSquare(to)
+}
+
+// Takes a dropped piece back off the board, leaving the pocket to the rollback
+func (board *Board) undoDrop(move Move) {
+	piece := move.DropPiece()
+	to := move.GetTo()
+	board.pieces[to] = EmptySquare
+	board.pieceBitboards[piece].ClearSquare(to)
+	board.colorBitboards[board.whoseTurn].ClearSquare(to)
+}
+
+const backRanks = Bitboard(0xFF000000000000FF)
+
+// Adds a drop for every piece in the pocket onto every empty square,
+// or only the squares that block a check
+func (board *Board) genDrops(ml *MoveList, allPieces Bitboard) {
+	targets := ^allPieces
+	if board.inCheck {
+		targets &= board.checkMask
+	}
+	pocket := &board.pockets[board.whoseTurn]
+	for piece := Pawn; piece < King; piece++ {
+		if pocket[piece] == 0 {
+			continue
+		}
+		squares := targets
+		if piece == Pawn {
+			squares &^= backRanks
+		}
+		for squares > 0 {
+			ml.Add(NewDrop(piece, squares.PopLSB()))
+		}
+	}
+}
+
+// Whether a drop could come out of GenMoves, ignoring checks
+func (board *Board) isPseudoLegalDrop(move Move) bool {
+	piece := move.DropPiece()
+	to := move.GetTo()
+	allPieces := board.colorBitboards[White] | board.colorBitboards[Black]
+	return board.variant == Crazyhouse && move.GetFrom() == to &&
+		move.GetFlag() == NoFlag && board.InPocket(board.whoseTurn, piece) > 0 &&
+		!allPieces.QuerySquare(to) &&
+		(piece != Pawn || !backRanks.QuerySquare(to))
+}
+
+// Parses the pieces between the brackets of a Crazyhouse FEN, like [QNpp]
+func parsePockets(fen, pocketString string, board *Board) error {
+	for _, letter := range pocketString {
+		piece := pieceNumFromLetter(unicode.ToLower(letter))
+		if piece == EmptySquare || piece == King {
+			return fenError(fen, "can't have '%c' in a pocket", letter)
+		}
+		color := Black
+		if unicode.IsUpper(letter) {
+			color = White
+		}
+		if board.pockets[color][piece] == maxPocket {
+			return fenError(fen, "too many '%c' in a pocket", letter)
+		}
+		board.pockets[color][piece]++
+	}
+	return nil
+}
+
+// White's pocket then black's, most valuable pieces first
+func (board *Board) pocketString() string {
+	var sb strings.Builder
+	for color := White; color <= Black; color++ {
+		for piece := Queen; piece >= Pawn; piece-- {
+			for i := uint8(0); i < board.pockets[color][piece]; i++ {
+				sb.WriteByte(pieceLetterFromNum(piece, color))
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,211 @@
+package board
+
+import (
+	"testing"
+
+	"20hh/engine/util"
+)
+
+func TestCrazyhousePerft(t *testing.T) {
+	SetupTables()
+	board, err := ParseVariantFEN(StartFEN, Crazyhouse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Drops only start to matter at depth 5
+	for depth, expected := range []int{20, 400, 8902, 197281, 4888832} {
+		if actual := board.perft(depth + 1); actual != expected {
+			t.Errorf("Depth %d counted %d nodes instead of %d", depth+1, actual, expected)
+		}
+	}
+}
+
+func TestCrazyhouseFEN(t *testing.T) {
+	SetupTables()
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1",
+		"r1bqk2r/pppp1ppp/2n5/4p3/1bB1n3/2N2N2/PPPP1PPP/R1BQK2R[Pp] w KQkq - 0 6",
+		"4k3/8/8/8/8/8/8/Q~3K3[QRRBNNPPPqp] b - - 3 40",
+	}
+	for _, fen := range fens {
+		board, err := ParseVariantFEN(fen, Crazyhouse)
+		if err != nil {
+			t.Errorf("%s", err)
+			continue
+		}
+		if output := board.FEN(); output != fen {
+			t.Errorf("%s written back as %s", fen, output)
+		}
+	}
+
+	board, _ := ParseVariantFEN(fens[2], Crazyhouse)
+	if board.InPocket(White, Rook) != 2 || board.InPocket(Black, Queen) != 1 ||
+		board.InPocket(Black, Knight) != 0 {
+		t.Errorf("Pockets parsed as [%s]", board.pocketString())
+	}
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/4K3[K] w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3[Q w - - 0 1",
+		"4k3/8/8/8/8/8/8/~4K3[] w - - 0 1",
+		"4k3/8/8/8/8/8/8/4K3[X] w - - 0 1",
+	} {
+		if _, err := ParseVariantFEN(fen, Crazyhouse); err == nil {
+			t.Errorf("Parsed \"%s\" without an error", fen)
+		}
+	}
+	if _, err := ParseFEN(fens[0]); err == nil {
+		t.Errorf("Parsed pockets outside of Crazyhouse")
+	}
+}
+
+func TestCrazyhouseCaptures(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		move     string
+		expected string
+	}{
+		{"Real piece", "4k3/8/8/8/8/8/8/3qK3[] w - - 0 1", "e1d1", "4k3/8/8/8/8/8/8/3K4[Q] b - - 0 1"},
+		{"Promoted piece", "3k4/8/8/8/8/8/8/q~2RK3[] w - - 0 1", "d1a1", "3k4/8/8/8/8/8/8/R3K3[P] b - - 0 1"},
+		{"Promotion", "4k3/1P6/8/8/8/8/8/4K3[] w - - 0 1", "b7b8q", "1Q~2k3/8/8/8/8/8/8/4K3[] b - - 0 1"},
+		{"Moving a promoted piece", "1Q~2k3/8/8/8/8/8/8/4K3[] w - - 0 1", "b8b2", "4k3/8/8/8/8/8/1Q~6/4K3[] b - - 1 1"},
+		{"En passant", "4k3/8/8/3pP3/8/8/8/4K3[] w - d6 0 1", "e5d6", "4k3/8/3P4/8/8/8/8/4K3[P] b - - 0 1"},
+		{"Drop", "4k3/8/8/8/8/8/8/4K3[Qp] w - - 0 1", "Q@e2", "4k3/8/8/8/8/8/4Q3/4K3[p] b - - 1 1"},
+	}
+	for _, tt := range tests {
+		board, err := ParseVariantFEN(tt.fen, Crazyhouse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := board.Hash()
+		board.UCIMakeMove(tt.move)
+		if fen := board.FEN(); fen != tt.expected {
+			t.Errorf("%s: %s gave %s instead of %s", tt.name, tt.move, fen, tt.expected)
+		}
+		expected, _ := ParseVariantFEN(tt.expected, Crazyhouse)
+		if board.Hash() != expected.Hash() {
+			t.Errorf("%s: hash doesn't match the FEN", tt.name)
+		}
+
+		board.UndoMove(findMove(t, tt.fen, tt.move))
+		if fen := board.FEN(); fen != tt.fen || board.Hash() != hash {
+			t.Errorf("%s: undoing %s gave %s", tt.name, tt.move, fen)
+		}
+	}
+}
+
+// The move a UCI string stands for in a Crazyhouse position
+func findMove(t *testing.T, fen, uci string) Move {
+	board, _ := ParseVariantFEN(fen, Crazyhouse)
+	var moves MoveList
+	board.GenLegalMoves(&moves, false)
+	for _, move := range moves.Slice() {
+		if board.MoveToUCI(move) == uci {
+			return move
+		}
+	}
+	t.Fatalf("%s isn't legal in %s", uci, fen)
+	return NullMove
+}
+
+func TestDropNotation(t *testing.T) {
+	SetupTables()
+	board, _ := ParseVariantFEN("6rk/6pp/8/8/8/8/8/K7[Np] w - - 0 1", Crazyhouse)
+	drop := NewDrop(Knight, F7)
+	if drop.String() != "N@f7" {
+		t.Errorf("Drop written for UCI as %s", drop)
+	}
+	if san := board.MoveToSAN(drop); san != "N@f7#" {
+		t.Errorf("Drop written in SAN as %s", san)
+	}
+	if move, err := board.ParseSAN("N@f7#"); err != nil || move != drop {
+		t.Errorf("N@f7# parsed as %s (%v)", move, err)
+	}
+	if _, err := board.ParseSAN("Q@f7"); err == nil {
+		t.Errorf("Dropped a piece that isn't in the pocket")
+	}
+	board.UCIMakeMove("a1b1")
+	if move, err := board.ParseSAN("@e4"); err != nil || move != NewDrop(Pawn, E4) {
+		t.Errorf("@e4 parsed as %s (%v)", move, err)
+	}
+	if board.IsLegal(NewDrop(Pawn, E1)) {
+		t.Errorf("Pawn drop on the back rank is legal")
+	}
+}
+
+func TestBadUCIDrops(t *testing.T) {
+	SetupTables()
+	for _, tt := range []struct {
+		variant Variant
+		move    string
+	}{
+		{Standard, "P@e4"},
+		// Nothing in white's pocket yet
+		{Crazyhouse, "P@e4"},
+		{Crazyhouse, "K@e4"},
+		{Crazyhouse, "X@e4"},
+		{Crazyhouse, "P@e9"},
+	} {
+		board, _ := ParseVariantFEN(StartFEN, tt.variant)
+		before := board.FEN()
+		board.UCIMakeMove(tt.move)
+		if fen := board.FEN(); fen != before {
+			t.Errorf("%s changed the board to %s", tt.move, fen)
+		}
+	}
+}
+
+// Drops made by brute force onto every empty square, keeping the ones that
+// don't leave the king in check, should be exactly the ones GenLegalMoves gives
+func TestCrazyhouseRandomGames(t *testing.T) {
+	SetupTables()
+	util.RandInit(0x3c6ef372fe94f82b)
+	for game := 0; game < 30; game++ {
+		board, _ := ParseVariantFEN(StartFEN, Crazyhouse)
+		for ply := 0; ply < 120; ply++ {
+			var moves MoveList
+			board.GenLegalMoves(&moves, false)
+			if moves.Count == 0 {
+				break
+			}
+
+			generated := make(map[Move]bool)
+			for _, move := range moves.Slice() {
+				if move.IsDrop() {
+					generated[move] = true
+				}
+			}
+			bruteForce := 0
+			allPieces := board.colorBitboards[White] | board.colorBitboards[Black]
+			for piece := Pawn; piece < King; piece++ {
+				if board.InPocket(board.whoseTurn, piece) == 0 {
+					continue
+				}
+				for sq := Square(0); sq < 64; sq++ {
+					if allPieces.QuerySquare(sq) || (piece == Pawn && (sq < 8 || sq >= 56)) {
+						continue
+					}
+					drop := NewDrop(piece, sq)
+					if board.MakeMove(drop) {
+						board.UndoMove(drop)
+						bruteForce++
+						if !generated[drop] {
+							t.Fatalf("%s: %s wasn't generated", board.FEN(), drop)
+						}
+					}
+				}
+			}
+			if bruteForce != len(generated) {
+				t.Fatalf("%s: generated %d drops instead of %d", board.FEN(), len(generated), bruteForce)
+			}
+
+			playRandomMove(&board)
+			fromFEN, err := ParseVariantFEN(board.FEN(), Crazyhouse)
+			if err != nil || fromFEN.FEN() != board.FEN() {
+				t.Fatalf("%s didn't survive a round trip (%v)", board.FEN(), err)
+			}
+		}
+	}
+}
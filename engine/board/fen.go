@@ -1,6 +1,8 @@
 package board
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -24,59 +26,174 @@ func pieceNumFromLetter(pieceLetter rune) Piece {
 	}
 }
 
+func pieceLetterFromNum(piece Piece, color int) byte {
+	letter := "-pnbrqk"[piece]
+	if color == White {
+		letter -= 'a' - 'A'
+	}
+	return letter
+}
+
+// Like ParseFEN, but panics on an invalid FEN
+// Only meant for FENs known to be good (start position, tests)
 func FromFEN(fen string) Board {
+	boardState, err := ParseFEN(fen)
+	if err != nil {
+		panic(err)
+	}
+	return boardState
+}
+
+func fenError(fen, format string, args ...any) error {
+	return fmt.Errorf("invalid FEN \"%s\": %s", fen, fmt.Sprintf(format, args...))
+}
+
+// Parses a FEN string, validating every field
+// The halfmove clock and fullmove number can be left off (as in EPD),
+// in which case they default to 0 and 1
+func ParseFEN(fen string) (Board, error) {
+	return ParseVariantFEN(fen, Standard)
+}
+
+// Like ParseFEN, but the board plays by a variant's rules
+// Three-check FENs can add check counters, which default to none given
+func ParseVariantFEN(fen string, variant Variant) (Board, error) {
 	boardState := NewBoard()
+	boardState.variant = variant
 
 	fields := strings.Fields(fen)
-	rankStrings := strings.Split(fields[0], "/")
+	if variant == ThreeCheck {
+		var err error
+		fields, err = parseCheckCounters(fen, fields, &boardState)
+		if err != nil {
+			return boardState, err
+		}
+	}
+	if len(fields) < 4 {
+		return boardState, fenError(fen, "expected at least 4 fields, got %d", len(fields))
+	}
+	if len(fields) > 6 {
+		return boardState, fenError(fen, "expected at most 6 fields, got %d", len(fields))
+	}
+
+	// Crazyhouse pockets come after the pieces, like [QNpp]
+	placement, pocketString, hasPockets := strings.Cut(fields[0], "[")
+	if hasPockets {
+		if variant != Crazyhouse {
+			return boardState, fenError(fen, "pockets are only used in Crazyhouse")
+		}
+		if !strings.HasSuffix(pocketString, "]") {
+			return boardState, fenError(fen, "pockets are missing a closing ']'")
+		}
+		err := parsePockets(fen, strings.TrimSuffix(pocketString, "]"), &boardState)
+		if err != nil {
+			return boardState, err
+		}
+	}
+
+	rankStrings := strings.Split(placement, "/")
+	if len(rankStrings) != 8 {
+		return boardState, fenError(fen, "expected 8 ranks, got %d", len(rankStrings))
+	}
 
 	// Piece Placement
 	for rank := 7; rank >= 0; rank-- {
 		rankString := rankStrings[7-rank]
 		file := uint8(0)
-		for _, pieceLetter := range rankString {
-			if unicode.IsDigit(pieceLetter) {
+		for i, pieceLetter := range rankString {
+			// Crazyhouse marks pieces that were pawns with a ~ after them
+			if pieceLetter == '~' {
+				if variant != Crazyhouse || i == 0 || !unicode.IsLetter(rune(rankString[i-1])) {
+					return boardState, fenError(fen, "unexpected '~' on rank %d", rank+1)
+				}
+				boardState.promoted.SetSquare(ConvertRankFile(uint8(rank), file-1))
+				continue
+			}
+			if file >= 8 {
+				return boardState, fenError(fen, "rank %d has more than 8 files", rank+1)
+			}
+			if pieceLetter >= '1' && pieceLetter <= '8' {
 				emptyPiecesAmt := uint8(pieceLetter - '0')
 				file += emptyPiecesAmt
-			} else {
-				idx := ConvertRankFile(uint8(rank), file)
-				piece := pieceNumFromLetter(unicode.ToLower(pieceLetter))
-				boardState.pieceBitboards[piece].SetSquare(idx)
-				boardState.pieces[idx] = piece
-
-				color := Black
-				if unicode.IsUpper(pieceLetter) {
-					color = White
-				}
-				boardState.colorBitboards[color].SetSquare(idx)
-				file++
+				continue
 			}
+			piece := pieceNumFromLetter(unicode.ToLower(pieceLetter))
+			if piece == EmptySquare {
+				return boardState, fenError(fen, "unknown piece '%c' on rank %d", pieceLetter, rank+1)
+			}
+			idx := ConvertRankFile(uint8(rank), file)
+			boardState.pieceBitboards[piece].SetSquare(idx)
+			boardState.pieces[idx] = piece
+
+			color := Black
+			if unicode.IsUpper(pieceLetter) {
+				color = White
+			}
+			boardState.colorBitboards[color].SetSquare(idx)
+			file++
+		}
+		if file != 8 {
+			return boardState, fenError(fen, "rank %d has %d files instead of 8", rank+1, file)
 		}
 	}
 
 	// Whose turn it is
-	if fields[1] == "w" {
+	switch fields[1] {
+	case "w":
 		boardState.whoseTurn = White
-	} else {
+	case "b":
 		boardState.whoseTurn = Black
+	default:
+		return boardState, fenError(fen, "side to move must be 'w' or 'b', not \"%s\"", fields[1])
 	}
 
-	// Castling rights
-	if fields[2] != "-" {
+	// Castling rights, as KQkq (X-FEN) or rook files (Shredder-FEN)
+	// Antichess doesn't have castling, so they're ignored there
+	if fields[2] != "-" && variant != Antichess {
 		for _, letter := range fields[2] {
-			castleMask := uint8(0)
-			switch letter {
-			case 'K':
-				castleMask = K
-			case 'Q':
-				castleMask = Q
-			case 'k':
-				castleMask = k
-			case 'q':
-				castleMask = q
+			color := Black
+			if unicode.IsUpper(letter) {
+				color = White
+			}
+			lower := unicode.ToLower(letter)
+
+			var queenside bool
+			var rookSq Square
+			switch {
+			case lower == 'k' || lower == 'q':
+				// The outermost rook, falling back on the corner
+				queenside = lower == 'q'
+				sq, ok := boardState.outermostRook(queenside, color)
+				if !ok {
+					sq = ConvertRankFile(backRank(color), H)
+					if queenside {
+						sq = ConvertRankFile(backRank(color), A)
+					}
+				}
+				rookSq = sq
+			case lower >= 'a' && lower <= 'h':
+				kingSq, ok := boardState.backRankKing(color)
+				if !ok {
+					return boardState, fenError(fen, "castling right '%c' without a king on the back rank", letter)
+				}
+				file := uint8(lower - 'a')
+				if file == kingSq%8 {
+					return boardState, fenError(fen, "castling right '%c' is on the king's file", letter)
+				}
+				queenside = file < kingSq%8
+				rookSq = ConvertRankFile(backRank(color), file)
+			default:
+				return boardState, fenError(fen, "unknown castling right '%c'", letter)
+			}
+
+			castleMask := castleRightMask(queenside, color)
+			if boardState.castleRights&castleMask > 0 {
+				return boardState, fenError(fen, "castling right '%c' repeated", letter)
 			}
 			boardState.castleRights |= castleMask
+			boardState.castleRookSqs[castleIdx(queenside, color)] = rookSq
 		}
+		boardState.chess960 = !boardState.standardCastling()
 	}
 
 	// En passant square
@@ -84,15 +201,41 @@ func FromFEN(fen string) Board {
 		boardState.enPassantSq = NoSq
 	} else {
 		squareString := fields[3]
-		file := uint8(squareString[0] - 'a')
-		rank := uint8(squareString[1] - '1')
-		boardState.enPassantSq = SquareOrNone(ConvertRankFile(rank, file))
+		sq, ok := parseSquare(squareString)
+		if !ok {
+			return boardState, fenError(fen, "\"%s\" is not a square", squareString)
+		}
+		expectedRank := Square(5)
+		if boardState.whoseTurn == Black {
+			expectedRank = 2
+		}
+		if sq/8 != expectedRank {
+			return boardState, fenError(fen, "en passant square %s is on the wrong rank", squareString)
+		}
+		boardState.enPassantSq = SquareOrNone(sq)
 	}
 
 	// Half & full moves
-	boardState.halfMoveClock = int(fields[4][0] - '0')
-	boardState.fullMoves = int(fields[5][0] - '0')
-	boardState.halfMoves = boardState.fullMoves * 2
+	boardState.halfMoveClock = 0
+	boardState.fullMoves = 1
+	if len(fields) > 4 {
+		halfMoveClock, err := strconv.Atoi(fields[4])
+		if err != nil || halfMoveClock < 0 {
+			return boardState, fenError(fen, "halfmove clock \"%s\" is not a non-negative number", fields[4])
+		}
+		boardState.halfMoveClock = halfMoveClock
+	}
+	if len(fields) > 5 {
+		fullMoves, err := strconv.Atoi(fields[5])
+		if err != nil || fullMoves < 0 {
+			return boardState, fenError(fen, "fullmove number \"%s\" is not a non-negative number", fields[5])
+		}
+		// Some sources write 0, which is treated as the first move
+		if fullMoves > 0 {
+			boardState.fullMoves = fullMoves
+		}
+	}
+	boardState.halfMoves = (boardState.fullMoves - 1) * 2
 	if boardState.whoseTurn == Black {
 		boardState.halfMoves++
 	}
@@ -100,6 +243,147 @@ func FromFEN(fen string) Board {
 	boardState.handleCheck()
 
 	boardState.genHash()
+	boardState.historyStart = boardState.halfMoves
+	boardState.recordPosition()
 
-	return boardState
+	return boardState, nil
+}
+
+// Serializes the board back into all six FEN fields
+// The en passant square is written whenever one is stored, which follows
+// the FEN spec of recording it after every double pawn push
+func (board *Board) FEN() string {
+	var sb strings.Builder
+
+	// Piece Placement
+	for rank := 7; rank >= 0; rank-- {
+		emptySquares := 0
+		for file := 0; file < 8; file++ {
+			idx := ConvertRankFile(uint8(rank), uint8(file))
+			piece := board.pieces[idx]
+			if piece == EmptySquare {
+				emptySquares++
+				continue
+			}
+			if emptySquares > 0 {
+				sb.WriteByte(byte('0' + emptySquares))
+				emptySquares = 0
+			}
+			color := White
+			if board.colorBitboards[Black].QuerySquare(idx) {
+				color = Black
+			}
+			sb.WriteByte(pieceLetterFromNum(piece, color))
+			if board.promoted.QuerySquare(idx) {
+				sb.WriteByte('~')
+			}
+		}
+		if emptySquares > 0 {
+			sb.WriteByte(byte('0' + emptySquares))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+	if board.variant == Crazyhouse {
+		sb.WriteString("[" + board.pocketString() + "]")
+	}
+
+	// Whose turn it is
+	if board.whoseTurn == White {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	// Castling rights
+	// Chess960 rights are written as X-FEN, using the file only when
+	// the rook isn't the outermost one and KQkq would be ambiguous
+	if board.castleRights == 0 {
+		sb.WriteByte('-')
+	} else {
+		for i, letter := range "KQkq" {
+			if board.castleRights&(1<<i) == 0 {
+				continue
+			}
+			queenside := i%2 == 1
+			color := i / 2
+			rookSq := board.castleRookSqs[i]
+			if outermost, _ := board.outermostRook(queenside, color); board.chess960 && outermost != rookSq {
+				letter = rune('a' + rookSq%8)
+				if color == White {
+					letter = unicode.ToUpper(letter)
+				}
+			}
+			sb.WriteRune(letter)
+		}
+	}
+
+	// En passant square
+	if board.enPassantSq == NoSq {
+		sb.WriteString(" -")
+	} else {
+		sb.WriteString(" " + squareName(Square(board.enPassantSq)))
+	}
+
+	// Checks each side has left to give
+	if board.variant == ThreeCheck {
+		sb.WriteString(fmt.Sprintf(" %d+%d",
+			checksToWin-board.checksGiven[White], checksToWin-board.checksGiven[Black]))
+	}
+
+	// Half & full moves
+	sb.WriteString(fmt.Sprintf(" %d %d", board.halfMoveClock, board.fullMoves))
+
+	return sb.String()
+}
+
+// The rook furthest toward the corner on one side of a king on its back rank
+func (board *Board) outermostRook(queenside bool, color int) (Square, bool) {
+	kingSq, ok := board.backRankKing(color)
+	if !ok {
+		return 0, false
+	}
+	rank := backRank(color)
+	friendlyRooks := board.pieceBitboards[Rook] & board.colorBitboards[color]
+	if queenside {
+		for file := uint8(A); file < kingSq%8; file++ {
+			if sq := ConvertRankFile(rank, file); friendlyRooks.QuerySquare(sq) {
+				return sq, true
+			}
+		}
+	} else {
+		for file := uint8(H); file > kingSq%8; file-- {
+			if sq := ConvertRankFile(rank, file); friendlyRooks.QuerySquare(sq) {
+				return sq, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (board *Board) backRankKing(color int) (Square, bool) {
+	kings := board.pieceBitboards[King] & board.colorBitboards[color]
+	for kings > 0 {
+		sq := kings.PopLSB()
+		if sq/8 == backRank(color) {
+			return sq, true
+		}
+	}
+	return 0, false
+}
+
+// Whether every castle right is the usual e-file king and corner rook
+func (board *Board) standardCastling() bool {
+	for i, rookSq := range board.castleRookSqs {
+		if board.castleRights&(1<<i) == 0 {
+			continue
+		}
+		color := i / 2
+		kingSq, ok := board.backRankKing(color)
+		if !ok || kingSq%8 != E || rookSq != [4]Square{H1, A1, H8, A8}[i] {
+			return false
+		}
+	}
+	return true
 }
@@ -2,6 +2,8 @@ package board
 
 import (
 	"testing"
+
+	"20hh/engine/util"
 )
 
 func compareBitboard(t *testing.T, actual, expected Bitboard, name string) {
@@ -119,3 +121,158 @@ func TestEnPassant(t *testing.T) {
 		t.Errorf("En passant set to %d instead of F6", enPassantSq)
 	}
 }
+
+func TestFENRoundTrip(t *testing.T) {
+	SetupTables()
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			board := FromFEN(position.fen)
+			if fen := board.FEN(); fen != position.fen {
+				t.Errorf("FEN exported as %s instead of %s", fen, position.fen)
+			}
+		})
+	}
+}
+
+// Shredder-FEN and X-FEN castling rights, and which FENs count as Chess960
+func TestChess960FEN(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		fen      string
+		expected string // X-FEN export
+	}{
+		// Shredder-FEN for outermost rooks comes back as KQkq
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+			"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9"},
+		// The inner rook needs its file
+		{"rk2r1r1/8/8/8/8/8/8/RK2R1R1 w Ee - 0 1", "rk2r1r1/8/8/8/8/8/8/RK2R1R1 w Ee - 0 1"},
+		{"rk2r1r1/8/8/8/8/8/8/RK2R1R1 w GAga - 0 1", "rk2r1r1/8/8/8/8/8/8/RK2R1R1 w KQkq - 0 1"},
+		{"1r2k1r1/8/8/8/8/8/8/1R2K1R1 w KQkq - 0 1", "1r2k1r1/8/8/8/8/8/8/1R2K1R1 w KQkq - 0 1"},
+	}
+	for _, tt := range tests {
+		board, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Errorf("%s failed to parse: %v", tt.fen, err)
+			continue
+		}
+		if !board.IsChess960() {
+			t.Errorf("%s wasn't detected as Chess960", tt.fen)
+		}
+		if fen := board.FEN(); fen != tt.expected {
+			t.Errorf("%s exported as %s instead of %s", tt.fen, fen, tt.expected)
+		}
+	}
+
+	// Standard castling is only Chess960 when asked for
+	board := StartPos()
+	if board.IsChess960() {
+		t.Error("The start position was detected as Chess960")
+	}
+	board.SetChess960(true)
+	if fen := board.FEN(); fen != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Errorf("Start position exported as %s in Chess960", fen)
+	}
+
+	for _, fen := range []string{
+		"rkr5/8/8/8/8/8/8/RKR5 w B - 0 1",   // King's file
+		"rkr5/8/8/8/8/8/8/RKR5 w CC - 0 1",  // Repeated
+		"rkr5/8/8/8/8/8/1K6/R1R5 w C - 0 1", // King off the back rank
+	} {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("%s parsed without an error", fen)
+		}
+	}
+}
+
+// Plays random games and checks the exported FEN rebuilds the same board
+// at every ply
+func TestFENRandomGames(t *testing.T) {
+	SetupTables()
+	util.RandInit(0x20b7a1f6e41c9d3b)
+	for game := 0; game < 20; game++ {
+		board := StartPos()
+		for ply := 0; ply < 100; ply++ {
+			fen := board.FEN()
+			reparsed := FromFEN(fen)
+			if reparsed.FEN() != fen {
+				t.Fatalf("Game %d ply %d: %s re-exported as %s",
+					game, ply, fen, reparsed.FEN())
+			}
+			if reparsed.pieces != board.pieces {
+				t.Fatalf("Game %d ply %d: %s rebuilt a different board",
+					game, ply, fen)
+			}
+
+			if _, ok := playRandomMove(&board); !ok {
+				break
+			}
+		}
+	}
+}
+
+func TestParseFENClocks(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name          string
+		fen           string
+		halfMoveClock int
+		fullMoves     int
+		halfMoves     int
+	}{
+		{"single digits", "8/8/8/4k3/8/8/8/4K3 w - - 3 7", 3, 7, 12},
+		{"multiple digits", "8/8/8/4k3/8/8/8/4K3 b - - 47 123", 47, 123, 245},
+		{"missing fullmove", "8/8/8/4k3/8/8/8/4K3 w - - 12", 12, 1, 0},
+		{"missing clocks", "8/8/8/4k3/8/8/8/4K3 b - -", 0, 1, 1},
+		{"zero fullmove", "8/8/8/4k3/8/8/8/4K3 w - - 0 0", 0, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("Failed to parse: %s", err)
+			}
+			if board.halfMoveClock != tt.halfMoveClock {
+				t.Errorf("Half move clock is %d instead of %d", board.halfMoveClock, tt.halfMoveClock)
+			}
+			if board.fullMoves != tt.fullMoves {
+				t.Errorf("Full moves is %d instead of %d", board.fullMoves, tt.fullMoves)
+			}
+			if board.halfMoves != tt.halfMoves {
+				t.Errorf("Total half moves is %d instead of %d", board.halfMoves, tt.halfMoves)
+			}
+		})
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name string
+		fen  string
+	}{
+		{"empty", ""},
+		{"too few fields", "8/8/8/4k3/8/8/8/4K3 w -"},
+		{"too many fields", "8/8/8/4k3/8/8/8/4K3 w - - 0 1 extra"},
+		{"too few ranks", "8/8/8/4k3/8/8/4K3 w - - 0 1"},
+		{"too many ranks", "8/8/8/8/4k3/8/8/8/4K3 w - - 0 1"},
+		{"too many files", "8/8/8/4k4/8/8/8/4K3 w - - 0 1"},
+		{"pieces past the last file", "8/8/8/4k3p/8/8/8/4K3 w - - 0 1"},
+		{"too few files", "8/8/8/4k2/8/8/8/4K3 w - - 0 1"},
+		{"unknown piece", "8/8/8/4k3/8/8/8/4X3 w - - 0 1"},
+		{"bad side to move", "8/8/8/4k3/8/8/8/4K3 x - - 0 1"},
+		{"bad castling right", "8/8/8/4k3/8/8/8/4K3 w KX - 0 1"},
+		{"repeated castling right", "8/8/8/4k3/8/8/8/4K3 w KK - 0 1"},
+		{"bad en passant square", "8/8/8/4k3/8/8/8/4K3 w - e9 0 1"},
+		{"en passant on wrong rank", "8/8/8/4k3/8/8/8/4K3 w - e3 0 1"},
+		{"bad halfmove clock", "8/8/8/4k3/8/8/8/4K3 w - - x 1"},
+		{"negative halfmove clock", "8/8/8/4k3/8/8/8/4K3 w - - -1 1"},
+		{"bad fullmove number", "8/8/8/4k3/8/8/8/4K3 w - - 0 1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFEN(tt.fen); err == nil {
+				t.Errorf("Parsed \"%s\" without an error", tt.fen)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package board
 
 func (b *Board) genHash() {
+	b.genPawnHash()
+	b.genMaterialHash()
+
 	newHash := uint64(0)
 
 	whiteBB, blackBB := b.ColorBitboards()
@@ -24,22 +27,74 @@ func (b *Board) genHash() {
 	if b.whoseTurn == White {
 		newHash ^= zVals.whiteToMove
 	}
+
+	newHash ^= zVals.checks[White][b.checksGiven[White]]
+	newHash ^= zVals.checks[Black][b.checksGiven[Black]]
+
+	for color := White; color <= Black; color++ {
+		for piece := Pawn; piece < King; piece++ {
+			newHash ^= pocketKeys[color][piece][b.pockets[color][piece]]
+		}
+	}
 	b.hash = newHash
 }
 
-// BROKEN (I THINK CASTLE RIGHTS IDK THO)
+func (b *Board) genPawnHash() {
+	b.pawnHash = 0
+	for color := White; color <= Black; color++ {
+		pawns := b.pieceBitboards[Pawn] & b.colorBitboards[color]
+		for pawns > 0 {
+			b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(pawns.PopLSB(), Pawn, color)]
+		}
+	}
+}
+
+// The material hash doesn't care where pieces are, so it reuses the piece
+// square numbers with the square standing in for how many there are:
+// the nth piece of a kind adds the number for square n - 1
+func (b *Board) genMaterialHash() {
+	b.materialHash = 0
+	for color := White; color <= Black; color++ {
+		for piece := Pawn; piece <= King; piece++ {
+			count := b.pieceCount(piece, color)
+			for n := uint8(0); n < count; n++ {
+				b.materialHash ^= zVals.pieceSquares[pieceSquareIdx(n, piece, color)]
+			}
+		}
+	}
+}
+
+// Adds or removes the last piece of a kind from the material hash,
+// given how many there are without it
+func (b *Board) toggleMaterial(piece Piece, color int, countWithout uint8) {
+	b.materialHash ^= zVals.pieceSquares[pieceSquareIdx(countWithout, piece, color)]
+}
+
+func (b *Board) pieceCount(piece Piece, color int) uint8 {
+	return uint8((b.pieceBitboards[piece] & b.colorBitboards[color]).PopCount())
+}
+
+// Updates the hash for a move that was just made, after the turn swaps
 func (b *Board) updateHash(m Move, moving, captured Piece, oldCastleRights uint8, hashedEPSq SquareOrNone) {
 	newHash := b.hash
 
 	movingColor := (b.whoseTurn + 1) % 2
 	capturedColor := b.whoseTurn
+	b.updatePawnAndMaterialHash(m, moving, captured, movingColor)
 	if captured > 0 {
 		// Remove the captured piece
 		newHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), captured, capturedColor)]
 	}
-	// Move piece to new square
-	newHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetFrom(), moving, movingColor)]
-	newHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), moving, movingColor)]
+	// Move piece to new square, where a pawn turns into what it promoted to
+	landing := moving
+	if m.HasFlag(Promotion) {
+		landing = promotionPiece(m)
+	}
+	// Drops come out of the pocket rather than off a square
+	if !m.IsDrop() {
+		newHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetFrom(), moving, movingColor)]
+	}
+	newHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), landing, movingColor)]
 
 	// Update castle rights
 	newHash ^= zVals.castleRights[oldCastleRights]
@@ -47,8 +102,9 @@ func (b *Board) updateHash(m Move, moving, captured Piece, oldCastleRights uint8
 
 	if m.HasFlag(Castle) && !m.HasFlag(Promotion) {
 		// Move rook if move was a castle
-		rookFrom := rookStartIdx(m.HasFlag(QueenCastle), movingColor)
-		rookTo := rookCastleIdx(rookFrom)
+		queenside := m.HasFlag(QueenCastle)
+		rookFrom := b.castleRookSq(queenside, movingColor)
+		rookTo := rookCastleDest(queenside, movingColor)
 		newHash ^= zVals.pieceSquares[pieceSquareIdx(rookFrom, Rook, movingColor)]
 		newHash ^= zVals.pieceSquares[pieceSquareIdx(rookTo, Rook, movingColor)]
 	}
@@ -69,7 +125,9 @@ func (b *Board) updateHash(m Move, moving, captured Piece, oldCastleRights uint8
 			sq := ConvertRankFile(rank, file)
 			newHash ^= zVals.pieceSquares[pieceSquareIdx(sq, Pawn, capturedColor)]
 		}
-	} else if b.enPassantPossible() {
+	}
+	// A new en passant square can replace the old one straight away
+	if b.enPassantPossible() {
 		file := b.enPassantSq % 8
 		newHash ^= zVals.enPassantFiles[file]
 	}
@@ -79,6 +137,50 @@ func (b *Board) updateHash(m Move, moving, captured Piece, oldCastleRights uint8
 	b.hash = newHash
 }
 
+// Updates the pawn and material hashes for a move that was just made
+// Castling never changes either of them
+func (b *Board) updatePawnAndMaterialHash(m Move, moving, captured Piece, movingColor int) {
+	capturedColor := (movingColor + 1) % 2
+	if captured > 0 {
+		b.toggleMaterial(captured, capturedColor, b.pieceCount(captured, capturedColor))
+		if captured == Pawn {
+			b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), Pawn, capturedColor)]
+		}
+	}
+	if m.IsDrop() {
+		b.toggleMaterial(moving, movingColor, b.pieceCount(moving, movingColor)-1)
+		if moving == Pawn {
+			b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), Pawn, movingColor)]
+		}
+		return
+	}
+	if moving != Pawn {
+		return
+	}
+
+	b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetFrom(), Pawn, movingColor)]
+	if m.HasFlag(Promotion) {
+		promoted := promotionPiece(m)
+		b.toggleMaterial(Pawn, movingColor, b.pieceCount(Pawn, movingColor))
+		b.toggleMaterial(promoted, movingColor, b.pieceCount(promoted, movingColor)-1)
+		return
+	}
+	b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(m.GetTo(), Pawn, movingColor)]
+	if m.HasFlag(EnPassant) {
+		b.toggleMaterial(Pawn, capturedColor, b.pieceCount(Pawn, capturedColor))
+		capturedSq := enPassantCaptureSq(m.GetTo(), movingColor)
+		b.pawnHash ^= zVals.pieceSquares[pieceSquareIdx(capturedSq, Pawn, capturedColor)]
+	}
+}
+
+// Updates the hash for a null move that was just made
+func (b *Board) updateNullMoveHash(hashedEPSq SquareOrNone) {
+	if hashedEPSq > NoSq {
+		b.hash ^= zVals.enPassantFiles[hashedEPSq%8]
+	}
+	b.hash ^= zVals.whiteToMove
+}
+
 func (b *Board) enPassantPossible() bool {
 	if b.enPassantSq == NoSq {
 		return false
@@ -147,9 +249,17 @@ var zVals = struct {
 	enPassantFiles [8]uint64   // Each possible file for en passant
 	castleRights   [16]uint64  // Each combination of castle rights
 	pieceSquares   [768]uint64 // Every piece on every square
+	// Checks given by each color in Three-check, which aren't part of
+	// Polyglot. None given hashes to 0 to keep other variants the same
+	checks [2][checksToWin + 1]uint64
 }{
 	whiteToMove: 0xf8d626aaaf278509,
 
+	checks: [2][checksToWin + 1]uint64{
+		{0, 0xb9096a04e7d80068, 0xc963cfe0afae5a3b, 0xe1454c40c439f34a},
+		{0, 0x26b563b1e794ee14, 0xac8be7d742840d2b, 0xd96e5adfa2beee31},
+	},
+
 	enPassantFiles: [8]uint64{
 		0x70cc73d90bc26e24, 0xe21a6b35df0c3ad7, 0x003a93d8b2806962, 0x1c99ded33cb890a1,
 		0xcf3145de0add4289, 0xd0e4427a5514fb72, 0x77c621cc9fb3a483, 0x67a34dac4356550b,
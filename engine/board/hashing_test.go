@@ -2,6 +2,8 @@ package board
 
 import (
 	"testing"
+
+	"20hh/engine/util"
 )
 
 func TestTranspositionHash(t *testing.T) {
@@ -69,6 +71,115 @@ func TestIncrementalHashUpdates(t *testing.T) {
 	}
 }
 
+func TestIncrementalPawnHashUpdates(t *testing.T) {
+	Init()
+
+	position1 := StartPos()
+	position1.UCIMakeMove("e2e4")
+	position1.UCIMakeMove("d7d5")
+	position1.UCIMakeMove("e4d5")
+	position1.UCIMakeMove("g8f6")
+	incrementalHash := position1.pawnHash
+	position1.genPawnHash()
+	fromScratchHash := position1.pawnHash
+
+	if incrementalHash != fromScratchHash {
+		t.Errorf("From scratch pawn hash (0x%x) != incremental update pawn hash (0x%x)",
+			fromScratchHash, incrementalHash,
+		)
+	}
+
+	// Only pawns count, so moving anything else leaves it alone
+	position1.UCIMakeMove("b1c3")
+	if position1.pawnHash != incrementalHash {
+		t.Errorf("Knight move changed the pawn hash from 0x%x to 0x%x",
+			incrementalHash, position1.pawnHash,
+		)
+	}
+}
+
+func TestIncrementalMaterialHashUpdates(t *testing.T) {
+	Init()
+
+	position1 := StartPos()
+	position1.UCIMakeMove("e2e4")
+	position1.UCIMakeMove("d7d5")
+	position1.UCIMakeMove("e4d5")
+	position1.UCIMakeMove("d8d5")
+	incrementalHash := position1.materialHash
+	position1.genMaterialHash()
+	fromScratchHash := position1.materialHash
+
+	if incrementalHash != fromScratchHash {
+		t.Errorf("From scratch material hash (0x%x) != incremental update material hash (0x%x)",
+			fromScratchHash, incrementalHash,
+		)
+	}
+
+	// Same material in a different spot
+	samePieces := FromFEN("rnb1kbnr/ppp1pppp/8/8/8/3q4/PPPPPPP1/RNBQKBNR w KQkq - 0 1")
+	if samePieces.materialHash != position1.materialHash {
+		t.Errorf("Material hash (0x%x) differs for the same material (0x%x)",
+			samePieces.materialHash, position1.materialHash,
+		)
+	}
+	startPosition := StartPos()
+	if startPosition.materialHash == position1.materialHash {
+		t.Errorf("Material hash is the same after pawns were traded")
+	}
+}
+
+// Random games from positions with promotions, castling and
+// en passant, checking the hash against one from scratch every move
+func TestIncrementalHashRandomGames(t *testing.T) {
+	Init()
+	util.RandInit(0x6a09e667f3bcc908)
+	var tests = []struct {
+		variant Variant
+		fen     string
+	}{
+		{Standard, "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8"},
+		{Standard, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1"},
+		// Both sides can take en passant after every double push
+		{Standard, "4k3/8/8/1p1p1p1p/P1P1P1P1/8/8/4K3 w - - 0 1"},
+		{Standard, "4k3/pppppppp/8/1P1P1P1P/p1p1p1p1/8/PPPPPPPP/4K3 w - - 0 1"},
+		{ThreeCheck, StartFEN},
+		// One check from the end, so games get to the third one
+		{ThreeCheck, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 1+1 0 1"},
+		{Crazyhouse, StartFEN},
+	}
+	for _, tt := range tests {
+		for game := 0; game < 20; game++ {
+			board, err := ParseVariantFEN(tt.fen, tt.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for ply := 0; ply < 60; ply++ {
+				move, ok := playRandomMove(&board)
+				if !ok {
+					break
+				}
+				incrementalHash := board.hash
+				incrementalPawnHash := board.pawnHash
+				incrementalMaterialHash := board.materialHash
+				board.genHash()
+				if board.hash != incrementalHash {
+					t.Fatalf("%s: hash after %s is 0x%x instead of 0x%x",
+						board.FEN(), move, incrementalHash, board.hash)
+				}
+				if board.pawnHash != incrementalPawnHash {
+					t.Fatalf("%s: pawn hash after %s is 0x%x instead of 0x%x",
+						board.FEN(), move, incrementalPawnHash, board.pawnHash)
+				}
+				if board.materialHash != incrementalMaterialHash {
+					t.Fatalf("%s: material hash after %s is 0x%x instead of 0x%x",
+						board.FEN(), move, incrementalMaterialHash, board.materialHash)
+				}
+			}
+		}
+	}
+}
+
 func TestPolyGlotHashingFEN(t *testing.T) {
 	Init()
 
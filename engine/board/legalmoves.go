@@ -0,0 +1,211 @@
+package board
+
+// Generates strictly legal moves, adding them to the end of ml
+// GenMoves already only gives safe king moves and check evasions,
+// so this just has to weed out moves by pinned pieces and the
+// rare en passant that uncovers an attack on the king
+func (board *Board) GenLegalMoves(ml *MoveList, capturesOnly bool) {
+	start := ml.Count
+	board.GenMoves(ml, capturesOnly)
+	// Nothing's pinned when the king can be taken
+	if board.variant == Antichess {
+		return
+	}
+
+	kingSq, hasKing := board.KingSquare(board.whoseTurn)
+	if !hasKing {
+		return
+	}
+	pinned := board.Pinned(board.whoseTurn)
+
+	legalIdx := start
+	for _, move := range ml.Moves[start:ml.Count] {
+		// King moves (including castling) are checked in GenMoves
+		if move.GetFrom() == kingSq ||
+			board.legalNonKingMove(move, kingSq, pinned) {
+			ml.Moves[legalIdx] = move
+			legalIdx++
+		}
+	}
+	ml.Count = legalIdx
+}
+
+// Returns whether a move can be played in the current position,
+// without having to make it first
+func (board *Board) IsLegal(move Move) bool {
+	// A move is only legal in Antichess if there's nothing to take
+	// instead, which is easiest to see from the moves themselves
+	if board.variant == Antichess {
+		var ml MoveList
+		board.GenMoves(&ml, false)
+		return ml.Contains(move)
+	}
+
+	board.handleCheck()
+	if !board.isPseudoLegal(move) {
+		return false
+	}
+
+	kingSq, hasKing := board.KingSquare(board.whoseTurn)
+	if !hasKing {
+		return true
+	}
+	if move.GetFrom() == kingSq {
+		// Castling squares are checked for attacks in isPseudoLegal
+		if move.GetFlag() == Castle || move.GetFlag() == QueenCastle {
+			return true
+		}
+		return !board.squareAttacked(move.GetTo(), SquareOrNone(kingSq))
+	}
+	return board.legalNonKingMove(move, kingSq, board.Pinned(board.whoseTurn))
+}
+
+// Where a color's king is, if it has one
+func (board *Board) KingSquare(color int) (Square, bool) {
+	kingMask := board.pieceBitboards[King] & board.colorBitboards[color]
+	if kingMask == 0 {
+		return 0, false
+	}
+	return kingMask.PopLSB(), true
+}
+
+// Checks a pseudo-legal move by anything other than the king
+// Relies on handleCheck having been called for this position
+func (board *Board) legalNonKingMove(move Move, kingSq Square, pinned Bitboard) bool {
+	from := move.GetFrom()
+	to := move.GetTo()
+	isEnPassant := move.GetFlag() == EnPassant
+
+	if board.doubleCheck {
+		return false
+	}
+	if board.inCheck && !board.checkMask.QuerySquare(to) {
+		if !isEnPassant ||
+			!board.checkMask.QuerySquare(enPassantCaptureSq(to, board.whoseTurn)) {
+			return false
+		}
+	}
+
+	// A pinned piece can only slide along the pin
+	if pinned.QuerySquare(from) && !LineMasks[kingSq][from].QuerySquare(to) {
+		return false
+	}
+
+	if isEnPassant {
+		return !board.enPassantExposesKing(from, to, kingSq)
+	}
+	return true
+}
+
+// En passant takes two pawns off the same rank at once,
+// which can open up a line to the king that pin detection misses
+func (board *Board) enPassantExposesKing(from, to, kingSq Square) bool {
+	capturedSq := enPassantCaptureSq(to, board.whoseTurn)
+	enemyBitboard := board.colorBitboards[(board.whoseTurn+1)%2]
+	enemyBitboard.ClearSquare(capturedSq)
+
+	blockers := board.colorBitboards[White] | board.colorBitboards[Black]
+	blockers.ClearSquare(from)
+	blockers.ClearSquare(capturedSq)
+	blockers.SetSquare(to)
+
+	enemyOrthoPieces := (board.pieceBitboards[Rook] |
+		board.pieceBitboards[Queen]) & enemyBitboard
+	if rookAttackBitboard(kingSq, blockers)&enemyOrthoPieces > 0 {
+		return true
+	}
+	enemyDiagPieces := (board.pieceBitboards[Bishop] |
+		board.pieceBitboards[Queen]) & enemyBitboard
+	return bishopAttackBitboard(kingSq, blockers)&enemyDiagPieces > 0
+}
+
+// Whether a move could come out of GenMoves in this position,
+// ignoring whether it leaves the king in check
+func (board *Board) isPseudoLegal(move Move) bool {
+	// Kings can only be promoted to in Antichess, which doesn't get here
+	if move == NullMove || move&kingPromotion > 0 {
+		return false
+	}
+	if move.IsDrop() {
+		return board.isPseudoLegalDrop(move)
+	}
+
+	whoseTurn := board.whoseTurn
+	friendlyBitboard := board.colorBitboards[whoseTurn]
+	enemyBitboard := board.colorBitboards[(whoseTurn+1)%2]
+	allPieces := friendlyBitboard | enemyBitboard
+
+	from := move.GetFrom()
+	to := move.GetTo()
+	flag := move.GetFlag()
+	if !friendlyBitboard.QuerySquare(from) {
+		return false
+	}
+	piece := board.pieces[from]
+
+	if flag == Castle || flag == QueenCastle {
+		if piece != King {
+			return false
+		}
+		var castles MoveList
+		board.genCastleMoves(&castles, from, allPieces)
+		return castles.Contains(move)
+	}
+
+	if friendlyBitboard.QuerySquare(to) {
+		return false
+	}
+	validFlag := flag == NoFlag || flag == Capture ||
+		(piece == Pawn && (flag == DblPawnMove || flag == EnPassant ||
+			flag&Promotion > 0))
+	if !validFlag {
+		return false
+	}
+	if flag == EnPassant {
+		return SquareOrNone(to) == board.enPassantSq &&
+			PawnAttacks[whoseTurn][from].QuerySquare(to)
+	}
+	isCapture := flag&Capture > 0
+	if isCapture != enemyBitboard.QuerySquare(to) {
+		return false
+	}
+
+	var attacks Bitboard
+	switch piece {
+	case Pawn:
+		lastRank := Square(7)
+		forward := int16(8)
+		if whoseTurn == Black {
+			lastRank = 0
+			forward = -8
+		}
+		if (flag&Promotion > 0) != (to/8 == lastRank) {
+			return false
+		}
+		if isCapture {
+			return PawnAttacks[whoseTurn][from].QuerySquare(to)
+		}
+		oneAhead := Square(int16(from) + forward)
+		if flag == DblPawnMove {
+			startRank := Square(1)
+			if whoseTurn == Black {
+				startRank = 6
+			}
+			return from/8 == startRank && !allPieces.QuerySquare(oneAhead) &&
+				to == Square(int16(oneAhead)+forward)
+		}
+		return to == oneAhead
+	case Knight:
+		attacks = KnightAttacks[from]
+	case Bishop:
+		attacks = bishopAttackBitboard(from, allPieces)
+	case Rook:
+		attacks = rookAttackBitboard(from, allPieces)
+	case Queen:
+		attacks = bishopAttackBitboard(from, allPieces) |
+			rookAttackBitboard(from, allPieces)
+	case King:
+		attacks = KingAttacks[from]
+	}
+	return attacks.QuerySquare(to)
+}
@@ -20,6 +20,12 @@ var BishopBlockerMasks [64]Bitboard
 var RookAttacks [64][]Bitboard
 var BishopAttacks [64][]Bitboard
 
+// Squares strictly between two squares on the same rank, file or diagonal
+var BetweenMasks [64][64]Bitboard
+
+// The full rank, file or diagonal going through two squares
+var LineMasks [64][64]Bitboard
+
 // Cardinal directions
 const (
 	North = 8
@@ -74,21 +80,14 @@ func SetupTables() {
 			PawnQuietMoves[Black][sq] |= sqBB >> South >> South
 		}
 		// Pawn attacks
-		PawnAttacks[White][sq] = ((whitePawnMove << East) & notA) |
-			((whitePawnMove >> West) & notH)
-		PawnAttacks[Black][sq] = ((blackPawnMove << East) & notA) |
-			((blackPawnMove >> West) & notH)
+		PawnAttacks[White][sq] = sqBB.NorthEast() | sqBB.NorthWest()
+		PawnAttacks[Black][sq] = sqBB.SouthEast() | sqBB.SouthWest()
 
 		// King moves/attacks
-		kingMoves := sqBB << North
-		kingMoves |= sqBB >> South
-		kingMoves |= (sqBB << East) & notA
-		kingMoves |= (sqBB >> West) & notH
-		kingMoves |= (sqBB << North << East) & notA
-		kingMoves |= (sqBB << North >> West) & notH
-		kingMoves |= (sqBB >> South << East) & notA
-		kingMoves |= (sqBB >> South >> West) & notH
-
+		kingMoves := Bitboard(0)
+		for _, dir := range Directions {
+			kingMoves |= sqBB.Shift(dir)
+		}
 		KingAttacks[sq] = kingMoves
 
 		// Knight moves/attacks
@@ -104,19 +103,37 @@ func SetupTables() {
 
 		KnightAttacks[sq] = knightMoves
 
-		// XOR so sq itself isn't included
-		RookBlockerMasks[sq] = RankMasksNoBorder[sq/8] ^ FileMasksNoBorder[sq%8]
+		// sq itself is in both masks unless it's on an edge
+		RookBlockerMasks[sq] = (RankMasksNoBorder[sq/8] | FileMasksNoBorder[sq%8]) &^ sqBB
 		BishopBlockerMasks[sq] = bishopBlockerMask(sq)
 
-		/*// Magics and Shifts are stored as literals
-		RookMagics[sq], RookAttacks[sq] = findMagicAtSq(sq, true)
-		BishopMagics[sq], BishopAttacks[sq] = findMagicAtSq(sq, false)
-		*/
-
 		setupRookTable(sq)
 		setupBishopTable(sq)
 	}
-	// printAllMagics()
+
+	for sq1 := Square(0); sq1 < 64; sq1++ {
+		for sq2 := Square(0); sq2 < 64; sq2++ {
+			setupLineMasks(sq1, sq2)
+		}
+	}
+}
+
+func setupLineMasks(sq1, sq2 Square) {
+	var sq1BB, sq2BB Bitboard
+	sq1BB.SetSquare(sq1)
+	sq2BB.SetSquare(sq2)
+
+	if rookMovesFromBlockers(sq1, 0).QuerySquare(sq2) {
+		BetweenMasks[sq1][sq2] = rookMovesFromBlockers(sq1, sq2BB) &
+			rookMovesFromBlockers(sq2, sq1BB)
+		LineMasks[sq1][sq2] = rookMovesFromBlockers(sq1, 0)&
+			rookMovesFromBlockers(sq2, 0) | sq1BB | sq2BB
+	} else if bishopMovesFromBlockers(sq1, 0).QuerySquare(sq2) {
+		BetweenMasks[sq1][sq2] = bishopMovesFromBlockers(sq1, sq2BB) &
+			bishopMovesFromBlockers(sq2, sq1BB)
+		LineMasks[sq1][sq2] = bishopMovesFromBlockers(sq1, 0)&
+			bishopMovesFromBlockers(sq2, 0) | sq1BB | sq2BB
+	}
 }
 
 func bishopBlockerMask(sq Square) Bitboard {
@@ -0,0 +1,260 @@
+package board
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"20hh/engine/util"
+)
+
+// Everything the slider lookups need, as found by FindMagics
+type MagicTables struct {
+	RookMagics   [64]uint64
+	RookShifts   [64]uint8
+	BishopMagics [64]uint64
+	BishopShifts [64]uint8
+}
+
+// What FindMagics aims for beyond magics that work at all
+type MagicOptions struct {
+	// Gives every square the shift of the biggest blocker mask, so the
+	// shift never has to be looked up, at the cost of bigger tables
+	FixedShift bool
+	// Tries for tables half the usual size on each square, which needs
+	// blocker arrangements with the same attacks to share entries
+	// Squares where that doesn't work out within MaxTries keep the usual size,
+	// searched for with at least the default number of tries
+	// With the default tries a handful of bishop squares shrink, mostly
+	// near the corners, while rooks practically never do
+	Denser bool
+	// How many candidate numbers to try per square before giving up
+	// on a denser table, or on the square altogether
+	MaxTries int
+	// Seeds the random numbers, so the same options give the same magics
+	Seed uint64
+}
+
+const (
+	defaultMagicTries = 1_000_000
+	// The most blocker squares a rook or bishop mask can have
+	maxRookBits   = 12
+	maxBishopBits = 9
+)
+
+// The tables in use right now
+func CurrentMagics() MagicTables {
+	return MagicTables{RookMagics, RookShifts, BishopMagics, BishopShifts}
+}
+
+// Searches for a magic number for every square
+// Expects SetupTables to have been called for the blocker masks
+func FindMagics(opts MagicOptions) (MagicTables, error) {
+	if opts.MaxTries <= 0 {
+		opts.MaxTries = defaultMagicTries
+	}
+	util.RandInit(opts.Seed)
+
+	var tables MagicTables
+	for sq := Square(0); sq < 64; sq++ {
+		var err error
+		tables.RookMagics[sq], tables.RookShifts[sq], err = findMagic(
+			newMagicSquare(sq, true), maxRookBits, opts)
+		if err != nil {
+			return tables, err
+		}
+		tables.BishopMagics[sq], tables.BishopShifts[sq], err = findMagic(
+			newMagicSquare(sq, false), maxBishopBits, opts)
+		if err != nil {
+			return tables, err
+		}
+	}
+	return tables, nil
+}
+
+// Every blocker arrangement a slider can see from a square,
+// along with the attacks each one gives
+type magicSquare struct {
+	sq          Square
+	rook        bool
+	mask        uint64
+	maskBits    int
+	blockers    []Bitboard
+	attacks     []Bitboard
+	table       []Bitboard
+	tableEpochs []int
+}
+
+func newMagicSquare(sq Square, rook bool) *magicSquare {
+	mask := BishopBlockerMasks[sq]
+	if rook {
+		mask = RookBlockerMasks[sq]
+	}
+	ms := &magicSquare{sq: sq, rook: rook, mask: uint64(mask), maskBits: mask.PopCount()}
+	ms.blockers = blockerPermutations(mask)
+	ms.attacks = make([]Bitboard, len(ms.blockers))
+	for i, blockers := range ms.blockers {
+		ms.attacks[i] = ms.bruteForce(blockers)
+	}
+	return ms
+}
+
+func (ms *magicSquare) bruteForce(blockers Bitboard) Bitboard {
+	if ms.rook {
+		return rookMovesFromBlockers(ms.sq, blockers)
+	}
+	return bishopMovesFromBlockers(ms.sq, blockers)
+}
+
+func (ms *magicSquare) name() string {
+	if ms.rook {
+		return fmt.Sprintf("rook on %s", squareName(ms.sq))
+	}
+	return fmt.Sprintf("bishop on %s", squareName(ms.sq))
+}
+
+// Whether every blocker arrangement lands on an entry that either hasn't
+// been used yet or already holds the same attacks
+// The epoch stamps save clearing the table between candidates
+func (ms *magicSquare) works(magic uint64, shift uint8, epoch int) bool {
+	for i, blockers := range ms.blockers {
+		index := (uint64(blockers) * magic) >> shift
+		if ms.tableEpochs[index] != epoch {
+			ms.tableEpochs[index] = epoch
+			ms.table[index] = ms.attacks[i]
+		} else if ms.table[index] != ms.attacks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func findMagic(ms *magicSquare, maxBits int, opts MagicOptions) (uint64, uint8, error) {
+	bits := ms.maskBits
+	switch {
+	case opts.FixedShift:
+		bits = maxBits
+	case opts.Denser:
+		bits--
+	}
+
+	tries := opts.MaxTries
+	for {
+		shift := uint8(64 - bits)
+		ms.table = make([]Bitboard, 1<<bits)
+		ms.tableEpochs = make([]int, 1<<bits)
+		for try := 1; try <= tries; {
+			var magic uint64
+			if bits < ms.maskBits {
+				// Sharing entries takes numbers that mix the blockers together,
+				// which is what sparse numbers are picked not to do
+				magic = util.RandU64()
+			} else {
+				magic = util.SparseRandU64()
+				// Magics that don't spread the mask over the top bits rarely work,
+				// and aren't worth counting as a try
+				if Bitboard((ms.mask*magic)&0xFF00000000000000).PopCount() < 6 {
+					continue
+				}
+			}
+			if ms.works(magic, shift, try) {
+				return magic, shift, nil
+			}
+			try++
+		}
+		if bits >= ms.maskBits {
+			return 0, 0, fmt.Errorf("no magic found for a %s in %d tries", ms.name(), tries)
+		}
+		// Settle for the usual size, which always turns up eventually
+		bits = ms.maskBits
+		tries = max(tries, defaultMagicTries)
+	}
+}
+
+// Checks every magic against the brute force attack generators,
+// returning the first square where they disagree
+func (tables *MagicTables) Verify() error {
+	for sq := Square(0); sq < 64; sq++ {
+		for _, ms := range []*magicSquare{newMagicSquare(sq, true), newMagicSquare(sq, false)} {
+			magic, shift := tables.BishopMagics[sq], tables.BishopShifts[sq]
+			if ms.rook {
+				magic, shift = tables.RookMagics[sq], tables.RookShifts[sq]
+			}
+			if shift < 64-maxRookBits || shift >= 64 {
+				return fmt.Errorf("%s has shift %d", ms.name(), shift)
+			}
+			ms.table = make([]Bitboard, 1<<(64-shift))
+			ms.tableEpochs = make([]int, 1<<(64-shift))
+			if !ms.works(magic, shift, 1) {
+				return fmt.Errorf("magic 0x%016x for a %s gives the wrong attacks", magic, ms.name())
+			}
+		}
+	}
+	return nil
+}
+
+// How many entries the rook and bishop attack tables need in total
+func (tables *MagicTables) TableSizes() (rook, bishop int) {
+	for sq := 0; sq < 64; sq++ {
+		rook += 1 << (64 - tables.RookShifts[sq])
+		bishop += 1 << (64 - tables.BishopShifts[sq])
+	}
+	return rook, bishop
+}
+
+// How many squares have tables smaller than their blocker masks need,
+// which only a Denser search finds
+func (tables *MagicTables) DenserSquares() (rook, bishop int) {
+	for sq := 0; sq < 64; sq++ {
+		if 64-int(tables.RookShifts[sq]) < RookBlockerMasks[sq].PopCount() {
+			rook++
+		}
+		if 64-int(tables.BishopShifts[sq]) < BishopBlockerMasks[sq].PopCount() {
+			bishop++
+		}
+	}
+	return rook, bishop
+}
+
+// Writes the tables out as a gofmt-ed Go source file for this package
+func (tables *MagicTables) WriteGo(w io.Writer, command string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// Code generated by \"%s\"; DO NOT EDIT.\n\n", command)
+	sb.WriteString("package board\n\n")
+	rook, bishop := tables.TableSizes()
+	fmt.Fprintf(&sb, "// Attack tables take up %d rook and %d bishop entries\n\n", rook, bishop)
+	writeMagics(&sb, "RookMagics", tables.RookMagics)
+	writeShifts(&sb, "RookShifts", tables.RookShifts)
+	writeMagics(&sb, "BishopMagics", tables.BishopMagics)
+	writeShifts(&sb, "BishopShifts", tables.BishopShifts)
+
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+func writeMagics(sb *strings.Builder, varName string, table [64]uint64) {
+	fmt.Fprintf(sb, "var %s = [64]uint64{", varName)
+	for sq := 0; sq < 64; sq++ {
+		if sq%4 == 0 {
+			sb.WriteString("\n\t")
+		}
+		fmt.Fprintf(sb, "0x%016x, ", table[sq])
+	}
+	sb.WriteString("\n}\n\n")
+}
+
+func writeShifts(sb *strings.Builder, varName string, table [64]uint8) {
+	fmt.Fprintf(sb, "var %s = [64]uint8{", varName)
+	for sq := 0; sq < 64; sq++ {
+		if sq%8 == 0 {
+			sb.WriteString("\n\t")
+		}
+		fmt.Fprintf(sb, "%d, ", table[sq])
+	}
+	sb.WriteString("\n}\n\n")
+}
@@ -0,0 +1,104 @@
+package board
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"20hh/engine/util"
+)
+
+func TestCurrentMagics(t *testing.T) {
+	SetupTables()
+	tables := CurrentMagics()
+	if err := tables.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	tables.RookMagics[D4] = 1
+	if err := tables.Verify(); err == nil {
+		t.Errorf("A broken rook magic on d4 passed verification")
+	}
+	tables = CurrentMagics()
+	tables.BishopShifts[C1] = 80
+	if err := tables.Verify(); err == nil {
+		t.Errorf("A shift of 80 passed verification")
+	}
+}
+
+func TestFindMagics(t *testing.T) {
+	SetupTables()
+	current := CurrentMagics()
+	currentRook, currentBishop := current.TableSizes()
+	for _, opts := range []MagicOptions{
+		{Seed: 1},
+		{FixedShift: true, Seed: 2},
+	} {
+		tables, err := FindMagics(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tables.Verify(); err != nil {
+			t.Fatal(err)
+		}
+
+		rook, bishop := tables.TableSizes()
+		if opts.FixedShift {
+			for sq := 0; sq < 64; sq++ {
+				if tables.RookShifts[sq] != 64-maxRookBits || tables.BishopShifts[sq] != 64-maxBishopBits {
+					t.Fatalf("Fixed shifts differ on %s", squareName(Square(sq)))
+				}
+			}
+		} else if rook != currentRook || bishop != currentBishop {
+			t.Errorf("Tables take %d and %d entries instead of %d and %d",
+				rook, bishop, currentRook, currentBishop)
+		}
+
+		again, _ := FindMagics(opts)
+		if again != tables {
+			t.Errorf("The same seed gave different magics")
+		}
+
+		var sb strings.Builder
+		if err := tables.WriteGo(&sb, "20hh gen-magics"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "magictables.go", sb.String(), 0); err != nil {
+			t.Errorf("Generated source doesn't parse: %v", err)
+		}
+	}
+}
+
+func TestFindDenserMagics(t *testing.T) {
+	SetupTables()
+	current := CurrentMagics()
+	currentRook, currentBishop := current.TableSizes()
+
+	// Too few tries to find anything denser, so every square falls back
+	tables, err := FindMagics(MagicOptions{Denser: true, MaxTries: 100, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tables.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if rook, bishop := tables.DenserSquares(); rook != 0 || bishop != 0 {
+		t.Errorf("%d rook and %d bishop squares shrank in 100 tries", rook, bishop)
+	}
+	if rook, bishop := tables.TableSizes(); rook != currentRook || bishop != currentBishop {
+		t.Errorf("Tables take %d and %d entries instead of %d and %d",
+			rook, bishop, currentRook, currentBishop)
+	}
+
+	// A bishop next to the corner shrinks within the default tries
+	util.RandInit(4)
+	ms := newMagicSquare(B1, false)
+	_, shift, err := findMagic(ms, maxBishopBits, MagicOptions{Denser: true, MaxTries: defaultMagicTries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bits := 64 - int(shift); bits != ms.maskBits-1 {
+		t.Errorf("Bishop on b1 uses %d bits instead of %d", bits, ms.maskBits-1)
+	}
+}
@@ -0,0 +1,65 @@
+// Code generated by "20hh gen-magics"; DO NOT EDIT.
+
+package board
+
+// Attack tables take up 102400 rook and 5248 bishop entries
+
+var RookMagics = [64]uint64{
+	0x4080002010400080, 0x8040100020004000, 0x0100104420000900, 0x8280100008008005,
+	0x0600100408220060, 0x1880020080040001, 0x2900008200010004, 0x2080024220801100,
+	0x5000800084384000, 0x0082400020005000, 0x0300801000802000, 0x8281001000082100,
+	0x0001000800041100, 0x0406000200040830, 0x0304000221101814, 0x4020800051002080,
+	0x2040008000308040, 0x00a0044010004020, 0x0041010018200040, 0x0110808008061000,
+	0x1086020008201004, 0x4401010008020400, 0xa400040001100208, 0x1002460002804401,
+	0x7218882080004000, 0x1400200080400082, 0x0040200080801000, 0x1020080080100080,
+	0x1708004040040200, 0x2846040080800200, 0x1004010400309208, 0x0d00008200040041,
+	0x0880002000400040, 0x0100402008401000, 0x1020108202004020, 0x0084402202000813,
+	0x1004008004800800, 0x5005000401000802, 0x0200211084000208, 0x0100084102000894,
+	0xc090804000208001, 0x8050084020084000, 0x0010002000108080, 0x0402090210010020,
+	0x0024000408008080, 0x0282000408020010, 0x4101081002040001, 0x0010010040820004,
+	0x00800060004000c0, 0x8000430082022a00, 0x0200200080100080, 0x0036100020090100,
+	0x4d04040080080080, 0x0020020080040080, 0x2809000422001100, 0x0500010040840200,
+	0x1002904221800101, 0x08c0820900104022, 0x0200600040b00903, 0x4010211000080501,
+	0x0052001004200902, 0x3002001008812422, 0x8080810082500814, 0x00049401006e8042,
+}
+
+var RookShifts = [64]uint8{
+	52, 53, 53, 53, 53, 53, 53, 52,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	53, 54, 54, 54, 54, 54, 54, 53,
+	52, 53, 53, 53, 53, 53, 53, 52,
+}
+
+var BishopMagics = [64]uint64{
+	0x0c04881804540140, 0x0008018102020200, 0x8410009a00400800, 0x0004042088010000,
+	0x0881104000082008, 0x0002021004014000, 0x60008208a0040324, 0x000a120801180800,
+	0x0080a0020202040a, 0x80010a0c092a0200, 0x0002048104010110, 0x0008040404890040,
+	0x1000420210004080, 0x2010411002504088, 0x00080402110420c0, 0xa080210080c42002,
+	0x8052080910101080, 0x2008943021480084, 0x0410000100420240, 0x0002002409220002,
+	0x0002100401040012, 0x400e0100c0462000, 0x4900508088080902, 0x02008200c6084100,
+	0x0403204008085004, 0x90880801041020a0, 0x0004044102160400, 0x2204040000401080,
+	0x080100401400404a, 0x0010010002088209, 0x00380104804c0230, 0x0011004c44220818,
+	0x8210092800201200, 0x400084a00404081a, 0x0000805000190400, 0x2086010040040040,
+	0x8008068400130500, 0x20c1100100042400, 0x4010090642121240, 0x008a020920345400,
+	0x0001011010094010, 0x2011290802022000, 0x2048824040400800, 0x0100420124090200,
+	0x8300480100400400, 0x5010200820400420, 0x0090828084020100, 0x106484084a400202,
+	0x28230c5002182100, 0x000441208820063c, 0x000000248c10000a, 0x0080300160884800,
+	0x4000700450440000, 0x004020850102004a, 0x0208a11102021000, 0x0810040808902000,
+	0x1003008041484000, 0x0404208200900400, 0x9005000624841024, 0x801290000020880c,
+	0x2604002804104400, 0x0240020484080202, 0x200010ca0a8c0400, 0x40c0280200982100,
+}
+
+var BishopShifts = [64]uint8{
+	58, 59, 59, 59, 59, 59, 59, 58,
+	59, 59, 59, 59, 59, 59, 59, 59,
+	59, 59, 57, 57, 57, 57, 59, 59,
+	59, 59, 57, 55, 55, 57, 59, 59,
+	59, 59, 57, 55, 55, 57, 59, 59,
+	59, 59, 57, 57, 57, 57, 59, 59,
+	59, 59, 59, 59, 59, 59, 59, 59,
+	58, 59, 59, 59, 59, 59, 59, 58,
+}
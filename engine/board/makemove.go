@@ -1,7 +1,25 @@
 package board
 
+import "unicode"
+
 // Parses a string fed by UCI to make a move
 func (board *Board) UCIMakeMove(moveString string) {
+	// Crazyhouse drops, like P@e4
+	if len(moveString) == 4 && moveString[1] == '@' {
+		piece := pieceNumFromLetter(unicode.ToLower(rune(moveString[0])))
+		to, ok := parseSquare(moveString[2:])
+		// Only pawns up to queens fit in a pocket, and the drop has to come
+		// out of it, or the pocket count would underflow
+		if !ok || piece < Pawn || piece > Queen {
+			return
+		}
+		drop := NewDrop(piece, to)
+		if board.isPseudoLegalDrop(drop) {
+			board.MakeMove(drop)
+		}
+		return
+	}
+
 	startFile := uint8(moveString[0] - 'a')
 	startRank := uint8(moveString[1] - '1')
 	endFile := uint8(moveString[2] - 'a')
@@ -38,6 +56,9 @@ func (board *Board) UCIMakeMove(moveString string) {
 				flag |= RookPromo
 			case 'q':
 				flag |= QueenPromo
+			case 'k':
+				board.MakeMove(NewKingPromotion(startSq, endSq, flag))
+				return
 			}
 		}
 	}
@@ -50,17 +71,41 @@ func (board *Board) UCIMakeMove(moveString string) {
 			fileDelta = startFile - endFile
 		}
 
-		if fileDelta > 1 {
-			flag |= Castle
-			if startFile > endFile {
-				flag |= QueenCastle
+		// Chess960 castling is written as the king taking its own rook
+		ownRook := capturedPiece == Rook &&
+			board.colorBitboards[board.whoseTurn].QuerySquare(endSq)
+		if ownRook || fileDelta > 1 {
+			queenside := startFile > endFile
+			flag = Castle
+			if queenside {
+				flag = QueenCastle
 			}
+			endSq = kingCastleDest(queenside, board.whoseTurn)
 		}
 	}
 
 	board.MakeMove(NewMove(startSq, endSq, flag))
 }
 
+// Formats a move for UCI, which writes Chess960 castling as the king taking its own rook
+func (board *Board) MoveToUCI(move Move) string {
+	flag := move.GetFlag()
+	if board.chess960 && (flag == Castle || flag == QueenCastle) {
+		return squareName(move.GetFrom()) + squareName(board.CastleRookSquare(move))
+	}
+	return move.String()
+}
+
+// Where the rook starts for a castling move, which is where
+// the king goes in notations that have it take its own rook
+func (board *Board) CastleRookSquare(move Move) Square {
+	color := White
+	if move.GetFrom()/8 == 7 {
+		color = Black
+	}
+	return board.castleRookSq(move.GetFlag() == QueenCastle, color)
+}
+
 // Returns false if the move is illegal
 func (board *Board) MakeMove(move Move) bool {
 	// Need to save this to update hash
@@ -81,6 +126,14 @@ func (board *Board) MakeMove(move Move) bool {
 
 	movingPiece := board.pieces[from]
 	capturedPiece := board.pieces[to]
+	// In Chess960 the king can land on its own rook when castling
+	isCastle := movingPiece == King && move.HasFlag(Castle)
+	if isCastle {
+		capturedPiece = EmptySquare
+	}
+	if move.IsDrop() {
+		movingPiece = move.DropPiece()
+	}
 
 	toRank := to / 8
 	isEnPassant := (toRank != 0 && toRank != 7) && move.HasFlag(EnPassant)
@@ -92,42 +145,43 @@ func (board *Board) MakeMove(move Move) bool {
 	friendlyBB := &board.colorBitboards[whoseTurn]
 	enemyBB := &board.colorBitboards[(whoseTurn+1)%2]
 
-	// Remove the moved piece from its previous position
-	movingPieceBB.ClearSquare(from)
-	friendlyBB.ClearSquare(from)
-	board.pieces[from] = EmptySquare
-
-	if isEnPassant {
-		passantedPawnPos := to - 8
-		if whoseTurn == Black {
-			passantedPawnPos = to + 8
-		}
-		board.capturedPieces.Push(Pawn)
-		board.pieceBitboards[Pawn].ClearSquare(passantedPawnPos)
-		enemyBB.ClearSquare(passantedPawnPos)
-		board.pieces[passantedPawnPos] = 0
-	} else if capturedPiece != EmptySquare {
-		board.capturedPieces.Push(capturedPiece)
-		capturedPieceBB.ClearSquare(to)
-		enemyBB.ClearSquare(to)
+	if board.variant == Crazyhouse && !isCastle {
+		board.updatePockets(move, capturedPiece)
 	}
 
-	if move.HasFlag(Promotion) {
-		// Conveniently, we can chop off everything but the last 2 bits
-		// of the flag and it's equal to the promotion piece - 2
-		promoPiece = Piece(2 + (move.GetFlag() & 0b11))
-		board.pieces[to] = promoPiece
-		board.pieceBitboards[promoPiece].SetSquare(to)
-	} else {
-		board.pieces[to] = movingPiece
-		movingPieceBB.SetSquare(to)
-	}
-	friendlyBB.SetSquare(to)
-
-	if movingPiece == King && move.HasFlag(Castle) {
+	if move.IsDrop() {
+		board.dropPiece(move)
+	} else if isCastle {
 		board.handleCastling(move)
 	} else {
-		board.updateCastleRights(from, to)
+		// Remove the moved piece from its previous position
+		movingPieceBB.ClearSquare(from)
+		friendlyBB.ClearSquare(from)
+		board.pieces[from] = EmptySquare
+
+		if isEnPassant {
+			passantedPawnPos := enPassantCaptureSq(to, whoseTurn)
+			board.capturedPieces.Push(Pawn)
+			board.pieceBitboards[Pawn].ClearSquare(passantedPawnPos)
+			enemyBB.ClearSquare(passantedPawnPos)
+			board.pieces[passantedPawnPos] = 0
+		} else if capturedPiece != EmptySquare {
+			board.capturedPieces.Push(capturedPiece)
+			capturedPieceBB.ClearSquare(to)
+			enemyBB.ClearSquare(to)
+		}
+
+		if move.HasFlag(Promotion) {
+			promoPiece = promotionPiece(move)
+			board.pieces[to] = promoPiece
+			board.pieceBitboards[promoPiece].SetSquare(to)
+		} else {
+			board.pieces[to] = movingPiece
+			movingPieceBB.SetSquare(to)
+		}
+		friendlyBB.SetSquare(to)
+
+		board.updateCastleRights(from, to, movingPiece)
 	}
 
 	if movingPiece == Pawn && !isEnPassant &&
@@ -154,8 +208,10 @@ func (board *Board) MakeMove(move Move) bool {
 		board.halfMoveClock++
 	}
 
-	// King move safety is checked in GenMoves
-	if movingPiece != King && board.squareAttacked(friendlyKingPos, NoSq) {
+	// King move safety is checked in GenMoves, and there's no such
+	// thing as check in Antichess
+	if movingPiece != King && board.variant != Antichess &&
+		board.squareAttacked(friendlyKingPos, NoSq) {
 		board.swapTurn()
 		board.UndoMove(move)
 		return false
@@ -163,7 +219,8 @@ func (board *Board) MakeMove(move Move) bool {
 	board.swapTurn()
 	board.updateHash(move, movingPiece, capturedPiece,
 		rollback.castleRights, hashedEPSq)
-	board.positionHistory[board.halfMoves] = board.hash
+	board.countCheck()
+	board.recordPosition()
 	return true
 }
 
@@ -178,6 +235,17 @@ func (board *Board) UndoMove(move Move) {
 	movedPiece := board.pieces[to]
 	movedPieceBB := &board.pieceBitboards[movedPiece]
 
+	if move.IsDrop() {
+		board.undoDrop(move)
+		board.restore(rollback)
+		return
+	}
+	if movedPiece == King && move.HasFlag(Castle) {
+		board.undoCastling(move)
+		board.restore(rollback)
+		return
+	}
+
 	fromRank := from / 8
 	isEnPassant := (fromRank == 3 || fromRank == 4) && move.HasFlag(EnPassant)
 
@@ -204,10 +272,7 @@ func (board *Board) UndoMove(move Move) {
 		capturedPieceBB := &board.pieceBitboards[capturedPiece]
 		enemyBB := &board.colorBitboards[(board.whoseTurn+1)%2]
 		if isEnPassant {
-			passantedPawnPos := to - 8
-			if board.whoseTurn == Black {
-				passantedPawnPos = to + 8
-			}
+			passantedPawnPos := enPassantCaptureSq(to, board.whoseTurn)
 			capturedPieceBB.SetSquare(passantedPawnPos)
 			board.pieces[passantedPawnPos] = Pawn
 			enemyBB.SetSquare(passantedPawnPos)
@@ -218,20 +283,12 @@ func (board *Board) UndoMove(move Move) {
 		}
 	}
 
-	if movedPiece == King && move.HasFlag(Castle) {
-		queenside := move.HasFlag(QueenCastle)
-		rookStart := rookStartIdx(queenside, board.whoseTurn)
-		rookEnd := rookCastleIdx(rookStart)
-
-		board.pieces[rookStart] = Rook
-		board.pieces[rookEnd] = 0
-
-		board.pieceBitboards[Rook].SetSquare(rookStart)
-		friendlyBB.SetSquare(rookStart)
-		board.pieceBitboards[Rook].ClearSquare(rookEnd)
-		friendlyBB.ClearSquare(rookEnd)
-	}
+	board.restore(rollback)
+}
 
+// Puts back everything a move changes besides the pieces
+// Expects the turn to already be swapped back
+func (board *Board) restore(rollback Rollback) {
 	board.halfMoves--
 	if board.whoseTurn == Black {
 		board.fullMoves--
@@ -243,5 +300,41 @@ func (board *Board) UndoMove(move Move) {
 	board.checkMask = rollback.checkMask
 	board.enPassantSq = rollback.enPassantSq
 	board.halfMoveClock = rollback.halfMoveClock
+	board.checksGiven = rollback.checksGiven
+	board.pockets = rollback.pockets
+	board.promoted = rollback.promoted
 	board.hash = rollback.hash
+	board.pawnHash = rollback.pawnHash
+	board.materialHash = rollback.materialHash
+	board.nullMovePly = rollback.nullMovePly
+}
+
+// Passes the turn without moving anything
+// Shouldn't be made while in check, since the king would be left in check
+func (board *Board) MakeNullMove() {
+	hashedEPSq := NoSq
+	if board.enPassantPossible() {
+		hashedEPSq = board.enPassantSq
+	}
+
+	board.rollbacks.Push(board.rollback())
+
+	board.enPassantSq = NoSq
+	board.halfMoves++
+	if board.whoseTurn == Black {
+		board.fullMoves++
+	}
+	board.halfMoveClock++
+	board.nullMovePly = board.halfMoves
+
+	board.swapTurn()
+	board.handleCheck()
+	board.updateNullMoveHash(hashedEPSq)
+	board.recordPosition()
+}
+
+func (board *Board) UndoNullMove() {
+	rollback := board.rollbacks.Pop()
+	board.swapTurn()
+	board.restore(rollback)
 }
@@ -1,25 +1,53 @@
 package board
 
-func rookStartIdx(queenside bool, whoseTurn int) Square {
-	rookIdx := H1 // White kingside
-	if whoseTurn == Black {
-		if queenside {
-			rookIdx = A8 // Black queenside
-		} else {
-			rookIdx = H8 // Black kingside
-		}
-	} else if queenside {
-		rookIdx = A1 // White queenside
+// Index into castleRookSqs, lining up with the bits of the castle rights
+func castleIdx(queenside bool, color int) int {
+	idx := color * 2
+	if queenside {
+		idx++
+	}
+	return idx
+}
+
+func castleRightMask(queenside bool, color int) uint8 {
+	return 1 << castleIdx(queenside, color)
+}
+
+// Where the castling rook starts, which only varies in Chess960
+func (board *Board) castleRookSq(queenside bool, color int) Square {
+	return board.castleRookSqs[castleIdx(queenside, color)]
+}
+
+// The king and rook always end up on the same squares, no matter where they started
+func kingCastleDest(queenside bool, color int) Square {
+	file := uint8(G)
+	if queenside {
+		file = C
+	}
+	return ConvertRankFile(backRank(color), file)
+}
+
+func rookCastleDest(queenside bool, color int) Square {
+	file := uint8(F)
+	if queenside {
+		file = D
 	}
-	return Square(rookIdx)
+	return ConvertRankFile(backRank(color), file)
 }
 
-func rookCastleIdx(startIdx Square) Square {
-	ret := startIdx - 2
-	if startIdx == A1 || startIdx == A8 {
-		ret = startIdx + 3
+func backRank(color int) uint8 {
+	if color == Black {
+		return 7
 	}
-	return ret
+	return 0
+}
+
+// Where the pawn taken by an en passant capture landing on sq sits
+func enPassantCaptureSq(sq Square, capturingColor int) Square {
+	if capturingColor == Black {
+		return sq + 8
+	}
+	return sq - 8
 }
 
 func (board *Board) handleCastling(move Move) {
@@ -30,38 +58,52 @@ func (board *Board) handleCastling(move Move) {
 	}
 	board.castleRights &^= rightsMask
 
-	friendlyBB := &board.colorBitboards[board.whoseTurn]
+	queenside := move.HasFlag(QueenCastle)
+	rookFrom := board.castleRookSq(queenside, board.whoseTurn)
+	rookTo := rookCastleDest(queenside, board.whoseTurn)
+	// In Chess960 the start and end squares can overlap,
+	// so both pieces are taken off before either is put back
+	board.movePair(move.GetFrom(), move.GetTo(), rookFrom, rookTo)
+}
 
+func (board *Board) undoCastling(move Move) {
 	queenside := move.HasFlag(QueenCastle)
-	rookIdx := rookStartIdx(queenside, board.whoseTurn)
-	// Castling is a king move, so just move the rook here
-	board.pieces[rookIdx] = EmptySquare
-	board.pieceBitboards[Rook].ClearSquare(rookIdx)
-	friendlyBB.ClearSquare(rookIdx)
-	newRookIdx := rookCastleIdx(rookIdx)
-	board.pieces[newRookIdx] = Rook
-	board.pieceBitboards[Rook].SetSquare(newRookIdx)
-	friendlyBB.SetSquare(newRookIdx)
+	rookFrom := board.castleRookSq(queenside, board.whoseTurn)
+	rookTo := rookCastleDest(queenside, board.whoseTurn)
+	board.movePair(move.GetTo(), move.GetFrom(), rookTo, rookFrom)
 }
 
-func (board *Board) updateCastleRights(from, to Square) {
-	if board.castleRights != 0 {
-		if to == H1 || from == H1 {
-			board.castleRights &^= K
-		} else if to == A1 || from == A1 {
-			board.castleRights &^= Q
-		}
+// Moves the king and rook of the side to move
+func (board *Board) movePair(kingFrom, kingTo, rookFrom, rookTo Square) {
+	friendlyBB := &board.colorBitboards[board.whoseTurn]
 
-		if to == H8 || from == H8 {
-			board.castleRights &^= k
-		} else if to == A8 || from == A8 {
-			board.castleRights &^= q
+	board.pieces[kingFrom] = EmptySquare
+	board.pieceBitboards[King].ClearSquare(kingFrom)
+	friendlyBB.ClearSquare(kingFrom)
+	board.pieces[rookFrom] = EmptySquare
+	board.pieceBitboards[Rook].ClearSquare(rookFrom)
+	friendlyBB.ClearSquare(rookFrom)
+
+	board.pieces[kingTo] = King
+	board.pieceBitboards[King].SetSquare(kingTo)
+	friendlyBB.SetSquare(kingTo)
+	board.pieces[rookTo] = Rook
+	board.pieceBitboards[Rook].SetSquare(rookTo)
+	friendlyBB.SetSquare(rookTo)
+}
+
+func (board *Board) updateCastleRights(from, to Square, movingPiece Piece) {
+	if board.castleRights != 0 {
+		if movingPiece == King {
+			board.castleRights &^= castleRightMask(false, board.whoseTurn) |
+				castleRightMask(true, board.whoseTurn)
 		}
 
-		if from == E1 {
-			board.castleRights &^= K | Q
-		} else if from == E8 {
-			board.castleRights &^= k | q
+		// Moving a rook or capturing one loses its right
+		for i, rookSq := range board.castleRookSqs {
+			if from == rookSq || to == rookSq {
+				board.castleRights &^= 1 << i
+			}
 		}
 	}
 }
@@ -2,15 +2,19 @@ package board
 
 import "fmt"
 
-type Move uint16
+type Move uint32
 
 // Be able to do bitwise between flags and full moves
 
 // MOVE STRUCTURE :
 // (copied from https://www.chessprogramming.org/Encoding_Moves)
 //
-// flags to     from
-// 0000  000000 000000
+// drop flags to     from
+// 000  0000  000000 000000
+//
+// Crazyhouse drops store the piece being dropped, with the drop
+// square as both from and to and no flags
+// Antichess promotions to a king set the bit above the drop piece
 
 // FLAGS
 const (
@@ -32,15 +36,28 @@ const (
 const (
 	SqMask   = 0x3F
 	FlagMask = 0xF
+	DropMask = 0x7
 )
 
 const NullMove = Move(0)
 
+const kingPromotion = Move(1 << 19)
+
 func NewMove(from, to Square, flag uint8) Move {
-	from16 := uint16(from)
-	toShifted := uint16(to) << 6
-	flagShifted := uint16(flag) << 12
-	return Move(from16 | toShifted | flagShifted)
+	from32 := uint32(from)
+	toShifted := uint32(to) << 6
+	flagShifted := uint32(flag) << 12
+	return Move(from32 | toShifted | flagShifted)
+}
+
+// Only possible in Antichess, where the king is an ordinary piece
+// The flag says whether the promotion captures
+func NewKingPromotion(from, to Square, flag uint8) Move {
+	return NewMove(from, to, flag&Capture|Promotion) | kingPromotion
+}
+
+func NewDrop(piece Piece, to Square) Move {
+	return NewMove(to, to, NoFlag) | Move(piece)<<16
 }
 
 func (move Move) GetFrom() Square {
@@ -56,7 +73,16 @@ func (move Move) GetFlag() uint8 {
 }
 
 func (move Move) HasFlag(flag uint8) bool {
-	return (uint16(move)>>12)&uint16(flag) == uint16(flag)
+	return (uint32(move)>>12)&uint32(flag) == uint32(flag)
+}
+
+func (move Move) IsDrop() bool {
+	return move.DropPiece() != EmptySquare
+}
+
+// The piece a Crazyhouse drop puts on the board, or EmptySquare
+func (move Move) DropPiece() Piece {
+	return Piece((move >> 16) & DropMask)
 }
 
 func fileName(sq Square) string {
@@ -64,7 +90,14 @@ func fileName(sq Square) string {
 	return string("abcdefgh"[file])
 }
 
+func squareName(sq Square) string {
+	return fmt.Sprintf("%s%d", fileName(sq), sq/8+1)
+}
+
 func (move Move) String() string {
+	if move.IsDrop() {
+		return fmt.Sprintf("%c@%s", pieceLetterFromNum(move.DropPiece(), White), squareName(move.GetTo()))
+	}
 	from := move.GetFrom()
 	fromRank := from/8 + 1
 	fromFile := fileName(from)
@@ -74,7 +107,7 @@ func (move Move) String() string {
 
 	flag := ""
 	if move.HasFlag(Promotion) {
-		flag = string("nbrq"[move.GetFlag()&0b11])
+		flag = string(pieceLetterFromNum(promotionPiece(move), Black))
 	}
 
 	return fmt.Sprintf("%s%d%s%d%s", fromFile, fromRank, toFile, toRank, flag)
@@ -1,27 +1,40 @@
 package board
 
-const (
-	whiteQCastleMask = Bitboard(1<<B1) | Bitboard(1<<C1) | Bitboard(1<<D1)
-	whiteKCastleMask = Bitboard(1<<F1) | Bitboard(1<<G1)
-	blackQCastleMask = Bitboard(1<<B8) | Bitboard(1<<C8) | Bitboard(1<<D8)
-	blackKCastleMask = Bitboard(1<<F8) | Bitboard(1<<G8)
-)
-
-func (board *Board) addMove(moves *[218]Move, moveIdx *int,
-	from, to uint8, flag uint8) {
-	// Prevents some (not all) non blocking moves from being added
-	if board.checkMask > 0 && Bitboard(1<<to)&board.checkMask == 0 {
-		return
+func (board *Board) addMove(ml *MoveList, from, to uint8, flag uint8) {
+	// Prevents moves that don't block or capture a checking piece
+	if board.checkMask > 0 && !board.checkMask.QuerySquare(to) {
+		// En passant can capture a checking pawn without landing on it
+		if flag != EnPassant ||
+			!board.checkMask.QuerySquare(enPassantCaptureSq(to, board.whoseTurn)) {
+			return
+		}
 	}
-	moves[*moveIdx] = NewMove(from, to, flag)
-	*moveIdx++
+	ml.Add(NewMove(from, to, flag))
 }
 
-// Generates pseudo-legal moves
+// Generates pseudo-legal moves, adding them to the end of ml
 // MakeMove undoes things like pins that aren't checked here
-func (board *Board) GenMoves(capturesOnly bool) ([]Move, int) {
-	var moves [218]Move
-	moveIdx := 0
+func (board *Board) GenMoves(ml *MoveList, capturesOnly bool) {
+	board.genMoves(ml, true, !capturesOnly)
+}
+
+// Generates pseudo-legal moves that don't capture anything,
+// including castling and promotions that don't capture
+func (board *Board) GenQuietMoves(ml *MoveList) {
+	board.genMoves(ml, false, true)
+}
+
+// Captures include en passant and promotions that capture
+func (board *Board) genMoves(ml *MoveList, captures, quiets bool) {
+	// Nothing can be played once a variant's rules end the game
+	if board.variant != Standard && board.VariantStatus() != Ongoing {
+		return
+	}
+
+	if board.variant == Antichess {
+		board.genAntichessMoves(ml, captures, quiets)
+		return
+	}
 
 	// First, check for check
 	board.handleCheck()
@@ -31,14 +44,20 @@ func (board *Board) GenMoves(capturesOnly bool) ([]Move, int) {
 	enemyBitboard := board.colorBitboards[(whoseTurn+1)%2]
 	allPieces := friendlyBitboard | enemyBitboard
 
+	// Squares pieces are allowed to move to
+	targets := ^friendlyBitboard
+	if !captures {
+		targets &^= enemyBitboard
+	}
+	if !quiets {
+		targets &= enemyBitboard
+	}
+
 	// King moves
 	friendlyKingMask := board.pieceBitboards[King] & friendlyBitboard
 	friendlyKingSq := friendlyKingMask.PopLSB()
 	kingAttacks := KingAttacks[friendlyKingSq]
-	kingAttacks &^= friendlyBitboard
-	if capturesOnly {
-		kingAttacks &= enemyBitboard
-	}
+	kingAttacks &= targets
 	for kingAttacks > 0 {
 		to := kingAttacks.PopLSB()
 		if board.squareAttacked(to, SquareOrNone(friendlyKingSq)) {
@@ -49,36 +68,42 @@ func (board *Board) GenMoves(capturesOnly bool) ([]Move, int) {
 		if board.pieces[to] > EmptySquare {
 			flag = Capture
 		}
-		moves[moveIdx] = NewMove(friendlyKingSq, to, flag)
-		moveIdx++
+		ml.Add(NewMove(friendlyKingSq, to, flag))
 	}
 	// when in double check, only king moves are allowed
 	if board.doubleCheck {
-		return moves[:moveIdx], moveIdx
+		return
+	}
+
+	if quiets {
+		board.genCastleMoves(ml, friendlyKingSq, allPieces)
 	}
 
-	if !capturesOnly {
-		board.genCastleMoves(&moves, &moveIdx, friendlyKingSq, allPieces)
+	board.genPawnMoves(ml, captures, quiets)
+	board.genPieceMoves(ml, targets, allPieces)
+
+	if quiets && board.variant == Crazyhouse {
+		board.genDrops(ml, allPieces)
 	}
+}
 
-	board.genPawnMoves(&moves, &moveIdx, capturesOnly)
+// Knight and slider moves onto the target squares
+func (board *Board) genPieceMoves(ml *MoveList, targets, allPieces Bitboard) {
+	friendlyBitboard := board.colorBitboards[board.whoseTurn]
 
 	// Knight moves
 	friendlyKnights := board.pieceBitboards[Knight] & friendlyBitboard
 	for friendlyKnights > 0 {
 		from := friendlyKnights.PopLSB()
 		attacks := KnightAttacks[from]
-		attacks &^= friendlyBitboard
-		if capturesOnly {
-			attacks &= enemyBitboard
-		}
+		attacks &= targets
 		for attacks > 0 {
 			to := attacks.PopLSB()
 			flag := NoFlag
 			if board.pieces[to] > EmptySquare {
 				flag = Capture
 			}
-			board.addMove(&moves, &moveIdx, from, to, flag)
+			board.addMove(ml, from, to, flag)
 		}
 	}
 
@@ -89,17 +114,14 @@ func (board *Board) GenMoves(capturesOnly bool) ([]Move, int) {
 	for friendlyOrthoPieces > 0 {
 		from := friendlyOrthoPieces.PopLSB()
 		attacks := rookAttackBitboard(from, allPieces)
-		attacks &^= friendlyBitboard
-		if capturesOnly {
-			attacks &= enemyBitboard
-		}
+		attacks &= targets
 		for attacks > 0 {
 			to := attacks.PopLSB()
 			flag := NoFlag
 			if board.pieces[to] > EmptySquare {
 				flag = Capture
 			}
-			board.addMove(&moves, &moveIdx, from, to, flag)
+			board.addMove(ml, from, to, flag)
 		}
 	}
 
@@ -108,66 +130,109 @@ func (board *Board) GenMoves(capturesOnly bool) ([]Move, int) {
 	for friendlyDiagPieces > 0 {
 		from := friendlyDiagPieces.PopLSB()
 		attacks := bishopAttackBitboard(from, allPieces)
-		attacks &^= friendlyBitboard
-		if capturesOnly {
-			attacks &= enemyBitboard
-		}
+		attacks &= targets
 		for attacks > 0 {
 			to := attacks.PopLSB()
 			flag := NoFlag
 			if board.pieces[to] > EmptySquare {
 				flag = Capture
 			}
-			board.addMove(&moves, &moveIdx, from, to, flag)
+			board.addMove(ml, from, to, flag)
+		}
+	}
+}
+
+// Antichess has no checks, and captures are compulsory,
+// so quiet moves only exist when there's nothing to take
+func (board *Board) genAntichessMoves(ml *MoveList, captures, quiets bool) {
+	start := ml.Count
+
+	friendlyBitboard := board.colorBitboards[board.whoseTurn]
+	enemyBitboard := board.colorBitboards[(board.whoseTurn+1)%2]
+	allPieces := friendlyBitboard | enemyBitboard
+
+	board.genAntichessKingMoves(ml, enemyBitboard)
+	board.genPawnMoves(ml, true, false)
+	board.genPieceMoves(ml, enemyBitboard, allPieces)
+	if ml.Count > start {
+		if !captures {
+			ml.Count = start
 		}
+		return
 	}
+	if !quiets {
+		return
+	}
+
+	board.genAntichessKingMoves(ml, ^allPieces)
+	board.genPawnMoves(ml, false, true)
+	board.genPieceMoves(ml, ^allPieces, allPieces)
+}
 
-	return moves[:moveIdx], moveIdx
+// Kings are ordinary pieces in Antichess, and there can be any number of them
+func (board *Board) genAntichessKingMoves(ml *MoveList, targets Bitboard) {
+	friendlyKings := board.pieceBitboards[King] & board.colorBitboards[board.whoseTurn]
+	for friendlyKings > 0 {
+		from := friendlyKings.PopLSB()
+		attacks := KingAttacks[from] & targets
+		for attacks > 0 {
+			to := attacks.PopLSB()
+			flag := NoFlag
+			if board.pieces[to] > EmptySquare {
+				flag = Capture
+			}
+			board.addMove(ml, from, to, flag)
+		}
+	}
 }
 
-func (board *Board) genCastleMoves(moves *[218]Move, moveIdx *int,
-	friendlyKingSq Square, allPieces Bitboard) {
+func (board *Board) genCastleMoves(ml *MoveList, friendlyKingSq Square, allPieces Bitboard) {
 	if board.castleRights == 0 || board.inCheck {
 		return
 	}
-	var hasRight bool
-	var isClear bool
-	var isSafe bool
 	whoseTurn := board.whoseTurn
-	if whoseTurn == White {
-		hasRight = board.castleRights&K > 0
-		isClear = whiteKCastleMask&allPieces == 0
-		isSafe = !board.squareAttacked(G1, NoSq) && !board.squareAttacked(F1, NoSq)
-		if hasRight && isClear && isSafe {
-			moves[*moveIdx] = NewMove(friendlyKingSq, G1, Castle)
-			*moveIdx++
+	for _, queenside := range [2]bool{false, true} {
+		if board.castleRights&castleRightMask(queenside, whoseTurn) == 0 {
+			continue
 		}
-		hasRight = board.castleRights&Q > 0
-		isClear = whiteQCastleMask&allPieces == 0
-		isSafe = !board.squareAttacked(C1, NoSq) && !board.squareAttacked(D1, NoSq)
-		if hasRight && isClear && isSafe {
-			moves[*moveIdx] = NewMove(friendlyKingSq, C1, QueenCastle)
-			*moveIdx++
+		rookSq := board.castleRookSq(queenside, whoseTurn)
+		if board.pieces[rookSq] != Rook {
+			continue
 		}
-	} else {
-		hasRight = board.castleRights&k > 0
-		isClear = blackKCastleMask&allPieces == 0
-		isSafe = !board.squareAttacked(G8, NoSq) && !board.squareAttacked(F8, NoSq)
-		if hasRight && isClear && isSafe {
-			moves[*moveIdx] = NewMove(friendlyKingSq, G8, Castle)
-			*moveIdx++
+		kingDest := kingCastleDest(queenside, whoseTurn)
+		rookDest := rookCastleDest(queenside, whoseTurn)
+
+		// Everything the king and rook pass over has to be empty,
+		// besides the king and rook themselves
+		blockers := allPieces
+		blockers.ClearSquare(friendlyKingSq)
+		blockers.ClearSquare(rookSq)
+		kingPath := BetweenMasks[friendlyKingSq][kingDest] | Bitboard(1)<<kingDest
+		rookPath := BetweenMasks[rookSq][rookDest] | Bitboard(1)<<rookDest
+		if (kingPath|rookPath)&blockers > 0 {
+			continue
 		}
-		hasRight = board.castleRights&q > 0
-		isClear = blackQCastleMask&allPieces == 0
-		isSafe = !board.squareAttacked(C8, NoSq) && !board.squareAttacked(D8, NoSq)
-		if hasRight && isClear && isSafe {
-			moves[*moveIdx] = NewMove(friendlyKingSq, C8, QueenCastle)
-			*moveIdx++
+
+		// The king can't pass through check. The rook is ignored as a
+		// blocker since it won't be shielding anything afterwards
+		isSafe := true
+		for kingPath > 0 {
+			if board.squareAttacked(kingPath.PopLSB(), SquareOrNone(rookSq)) {
+				isSafe = false
+				break
+			}
+		}
+		if isSafe {
+			flag := Castle
+			if queenside {
+				flag = QueenCastle
+			}
+			ml.Add(NewMove(friendlyKingSq, kingDest, flag))
 		}
 	}
 }
 
-func (board *Board) genPawnMoves(moves *[218]Move, moveIdx *int, capturesOnly bool) {
+func (board *Board) genPawnMoves(ml *MoveList, captures, quiets bool) {
 	whoseTurn := board.whoseTurn
 	friendlyBitboard := board.colorBitboards[whoseTurn]
 	enemyBitboard := board.colorBitboards[(whoseTurn+1)%2]
@@ -196,8 +261,11 @@ func (board *Board) genPawnMoves(moves *[218]Move, moveIdx *int, capturesOnly bo
 		}
 		attacks &= enemies
 
-		allPawnMoves := attacks
-		if !capturesOnly {
+		var allPawnMoves Bitboard
+		if captures {
+			allPawnMoves |= attacks
+		}
+		if quiets {
 			allPawnMoves |= quietMoves
 		}
 		for allPawnMoves > 0 {
@@ -219,10 +287,13 @@ func (board *Board) genPawnMoves(moves *[218]Move, moveIdx *int, capturesOnly bo
 			}
 
 			if !promotion {
-				board.addMove(moves, moveIdx, sq, to, flag)
+				board.addMove(ml, sq, to, flag)
 			} else {
 				for _, promoFlag := range promotionFlags {
-					board.addMove(moves, moveIdx, sq, to, flag|promoFlag)
+					board.addMove(ml, sq, to, flag|promoFlag)
+				}
+				if board.variant == Antichess {
+					ml.Add(NewKingPromotion(sq, to, flag))
 				}
 			}
 		}
@@ -241,59 +312,50 @@ func bishopAttackBitboard(sq Square, blockers Bitboard) Bitboard {
 	return BishopAttacks[sq][index]
 }
 
+// Works out whether the side to move is in check, and if only one piece is
+// giving check, which squares a non-king move has to land on to stop it
 func (board *Board) handleCheck() {
 	friendlyKingMask := board.colorBitboards[board.whoseTurn] &
 		board.pieceBitboards[King]
-	sq := friendlyKingMask.PopLSB()
 
 	board.inCheck = false
 	board.doubleCheck = false
 	board.checkMask = 0
 
+	// A FEN can describe a position without a king,
+	// and kings can't be in check in Antichess
+	if friendlyKingMask == 0 || board.variant == Antichess {
+		return
+	}
+	sq := friendlyKingMask.PopLSB()
+
 	oppositeColor := (board.whoseTurn + 1) % 2
 	enemyBitboard := board.colorBitboards[oppositeColor]
 	blockers := board.colorBitboards[White] | board.colorBitboards[Black]
 
-	rookAttacks := rookAttackBitboard(sq, blockers)
 	enemyOrthoPieces := (board.pieceBitboards[Rook] |
 		board.pieceBitboards[Queen]) & enemyBitboard
-	if enemyOrthoPieces&rookAttacks > 0 {
-		board.inCheck = true
-		board.checkMask = rookAttacks
-	}
-
-	bishopAttacks := bishopAttackBitboard(sq, blockers)
 	enemyDiagPieces := (board.pieceBitboards[Bishop] |
 		board.pieceBitboards[Queen]) & enemyBitboard
-	if enemyDiagPieces&bishopAttacks > 0 {
-		if board.inCheck {
-			board.doubleCheck = true
-			board.checkMask = 0
-			return
-		} else {
-			board.inCheck = true
-			board.checkMask = bishopAttacks
-		}
-	}
-
-	knightAttacks := KnightAttacks[sq]
 	enemyKnights := board.pieceBitboards[Knight] & enemyBitboard
-	if enemyKnights&knightAttacks > 0 {
-		if board.inCheck {
-			board.doubleCheck = true
-			board.checkMask = 0
-		} else {
-			board.inCheck = true
-		}
-	}
+	enemyPawns := board.pieceBitboards[Pawn] & enemyBitboard
+
+	checkers := rookAttackBitboard(sq, blockers) & enemyOrthoPieces
+	checkers |= bishopAttackBitboard(sq, blockers) & enemyDiagPieces
+	checkers |= KnightAttacks[sq] & enemyKnights
+	checkers |= PawnAttacks[board.whoseTurn][sq] & enemyPawns
 
-	if board.inCheck {
+	if checkers == 0 {
 		return
 	}
-
-	pawnAttacks := PawnAttacks[board.whoseTurn][sq]
-	enemyPawns := board.pieceBitboards[Pawn] & enemyBitboard
-	if enemyPawns&pawnAttacks > 0 {
-		board.inCheck = true
+	board.inCheck = true
+	if checkers&(checkers-1) > 0 {
+		board.doubleCheck = true
+		return
 	}
+
+	// Either block the checking piece or capture it
+	checkerSq := checkers.PopLSB()
+	board.checkMask = BetweenMasks[sq][checkerSq]
+	board.checkMask.SetSquare(checkerSq)
 }
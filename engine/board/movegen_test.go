@@ -1,33 +1,164 @@
 package board
 
 import (
-	"fmt"
 	"testing"
+
+	"20hh/engine/util"
 )
 
+type perftPosition struct {
+	name     string
+	fen      string
+	depth    int
+	expected int
+}
+
 // https://www.chessprogramming.org/Perft_Results
+var perftPositions = []perftPosition{
+	{"Starting pos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 5, 4865609},
+	{"Position 2", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 5, 193690690},
+	{"Position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
+	{"Position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 5, 15833292},
+	{"Position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 5, 89941194},
+	{"Position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", 5, 164075551},
+}
+
+// Plays one of the legal moves at random for the random game tests,
+// or returns false if there aren't any
+func playRandomMove(board *Board) (Move, bool) {
+	var moves MoveList
+	board.GenLegalMoves(&moves, false)
+	if moves.Count == 0 {
+		return NullMove, false
+	}
+	move := moves.Moves[util.RandU64()%uint64(moves.Count)]
+	board.MakeMove(move)
+	return move, true
+}
+
 func TestWithPerft(t *testing.T) {
+	SetupTables()
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			board := FromFEN(tt.fen)
+			actual := board.perft(tt.depth)
+			if actual != tt.expected {
+				t.Errorf(
+					"In %s perft returned %d instead of %d at depth %d",
+					tt.name,
+					actual,
+					tt.expected,
+					tt.depth,
+				)
+			}
+		})
+	}
+}
+
+// Chess960 positions from the Stockfish/Reinhard Scharnagl suites
+// Each one has castling with the king or rook already next to its destination
+func TestChess960Perft(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		fen      string
+		expected []int // Counts for depth 1, 2, 3...
+	}{
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []int{21, 528, 12189, 326672}},
+		{"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []int{21, 807, 18002, 667366}},
+		{"b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []int{20, 479, 10471, 273318}},
+		{"qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", []int{22, 593, 13440, 382958}},
+		{"1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9", []int{28, 1120, 31058, 1171749}},
+		{"qnbnr1kr/ppp1b1pp/4p3/3p1p2/8/2NPP3/PPP1BPPP/QNB1R1KR w HEhe - 1 9", []int{29, 899, 26578, 824055}},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		if !board.IsChess960() {
+			t.Errorf("%s wasn't detected as Chess960", tt.fen)
+		}
+		for depth, expected := range tt.expected {
+			if actual := board.perft(depth + 1); actual != expected {
+				t.Errorf("In %s perft returned %d instead of %d at depth %d",
+					tt.fen, actual, expected, depth+1)
+			}
+			if actual := board.legalPerft(depth + 1); actual != expected {
+				t.Errorf("In %s legal perft returned %d instead of %d at depth %d",
+					tt.fen, actual, expected, depth+1)
+			}
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
 	SetupTables()
 	var tests = []struct {
-		name     string
 		fen      string
-		depth    int
-		expected int
+		uciMove  string
+		expected string // FEN after castling
 	}{
-		{"Starting pos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 5, 4865609},
-		{"Position 2", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 5, 193690690},
-		{"Position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
-		{"Position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 5, 15833292},
-		{"Position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 5, 89941194},
-		{"Position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", 5, 164075551},
+		// King already on its destination, only the rook moves
+		{"1r4kr/8/8/8/8/8/8/1R4KR w HBhb - 0 1", "g1h1", "1r4kr/8/8/8/8/8/8/1R3RK1 b kq - 1 1"},
+		// King and rook swap squares
+		{"1r3kr1/8/8/8/8/8/8/4K3 b gb - 0 1", "f8g8", "1r3rk1/8/8/8/8/8/8/4K3 w - - 1 2"},
+		// Queenside with the king moving right
+		{"rk4r1/8/8/8/8/8/8/RK4R1 w GAga - 0 1", "b1a1", "rk4r1/8/8/8/8/8/8/2KR2R1 b kq - 1 1"},
+		// Standard castling is also written as taking the rook
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1h1", "r3k2r/8/8/8/8/8/8/R4RK1 b kq - 1 1"},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		board.SetChess960(true)
+		original := board.FEN()
+		castle := NullMove
+		var moves MoveList
+		board.GenLegalMoves(&moves, false)
+		for _, move := range moves.Slice() {
+			if board.MoveToUCI(move) == tt.uciMove {
+				castle = move
+			}
+		}
+		if castle == NullMove {
+			t.Errorf("%s has no castle written as %s", tt.fen, tt.uciMove)
+			continue
+		}
+
+		board.MakeMove(castle)
+		if fen := board.FEN(); fen != tt.expected {
+			t.Errorf("%s %s gave %s instead of %s", tt.fen, tt.uciMove, fen, tt.expected)
+		}
+		// The incremental hash should agree with one from scratch
+		hash := board.Hash()
+		board.genHash()
+		if board.Hash() != hash {
+			t.Errorf("%s %s hashed incorrectly", tt.fen, tt.uciMove)
+		}
+		board.UndoMove(castle)
+		if fen := board.FEN(); fen != original {
+			t.Errorf("%s %s undid to %s", tt.fen, tt.uciMove, fen)
+		}
+
+		board.UCIMakeMove(tt.uciMove)
+		if fen := board.FEN(); fen != tt.expected {
+			t.Errorf("%s %s from UCI gave %s instead of %s", tt.fen, tt.uciMove, fen, tt.expected)
+		}
 	}
+}
+
+// Checks GenLegalMoves against the same counts as TestWithPerft,
+// which is quick since the last ply is only counted
+func TestLegalPerft(t *testing.T) {
+	SetupTables()
+	// The usual positions plus a couple of en passant edge cases
+	tests := append(append([]perftPosition{}, perftPositions...),
+		perftPosition{"En passant pin", "8/8/3p4/KPp4r/1R3p1k/8/4P1P1/8 w - c6 0 2", 1, 16},
+		perftPosition{"En passant out of check", "8/8/8/2k5/3Pp3/8/8/4K3 b - d3 0 1", 1, 9},
+	)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			board := FromFEN(tt.fen)
-			actual := board.perft(tt.depth, false)
+			actual := board.legalPerft(tt.depth)
 			if actual != tt.expected {
 				t.Errorf(
-					"In %s perft returned %d instead of %d at depth %d",
+					"In %s legal perft returned %d instead of %d at depth %d",
 					tt.name,
 					actual,
 					tt.expected,
@@ -38,48 +169,76 @@ func TestWithPerft(t *testing.T) {
 	}
 }
 
-func (board *Board) perft(depth int, print bool) int {
-	if depth == 0 {
-		return 1
+// IsLegal should agree with MakeMove on every pseudo-legal move
+func TestIsLegal(t *testing.T) {
+	SetupTables()
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			board := FromFEN(position.fen)
+			var moves MoveList
+			board.GenMoves(&moves, false)
+			for _, move := range moves.Slice() {
+				isLegal := board.IsLegal(move)
+				madeMove := board.MakeMove(move)
+				if madeMove {
+					board.UndoMove(move)
+				}
+				if isLegal != madeMove {
+					t.Errorf("IsLegal(%s) returned %t", move, isLegal)
+				}
+			}
+		})
 	}
 
-	nodes := 0
-	moves, _ := board.GenMoves(false)
-	for _, move := range moves {
-		if print {
-			fmt.Println(move)
-		}
-		if !board.MakeMove(move) {
-			continue
-		}
-		nodes += board.perft(depth-1, print)
-		board.UndoMove(move)
-		if print {
-			fmt.Println("^")
+	board := StartPos()
+	var illegal = []Move{
+		NullMove,
+		NewMove(E2, E5, NoFlag),      // too far
+		NewMove(E7, E5, DblPawnMove), // not our piece
+		NewMove(B1, D2, NoFlag),      // own piece on target
+		NewMove(F1, C4, NoFlag),      // blocked bishop
+		NewMove(E1, G1, Castle),      // pieces in the way
+		NewMove(E2, E3, Capture),     // nothing to capture
+		NewMove(E2, E4, NoFlag),      // missing double push flag
+	}
+	for _, move := range illegal {
+		if board.IsLegal(move) {
+			t.Errorf("IsLegal(%s) accepted an illegal move", move)
 		}
 	}
+}
+
+func (board *Board) legalPerft(depth int) int {
+	var moves MoveList
+	board.GenLegalMoves(&moves, false)
+	if depth == 1 {
+		return moves.Count
+	}
 
+	nodes := 0
+	for _, move := range moves.Slice() {
+		board.MakeMove(move)
+		nodes += board.legalPerft(depth - 1)
+		board.UndoMove(move)
+	}
 	return nodes
 }
 
-func (board *Board) divide(depth int) int {
-	totalNodes := 0
-	moves, movesCount := board.GenMoves(false)
-	for i := 0; i <= movesCount; i++ {
-		if !board.MakeMove(moves[i]) {
+func (board *Board) perft(depth int) int {
+	if depth == 0 {
+		return 1
+	}
+
+	nodes := 0
+	var moves MoveList
+	board.GenMoves(&moves, false)
+	for _, move := range moves.Slice() {
+		if !board.MakeMove(move) {
 			continue
 		}
-		fmt.Printf("%s: ", moves[i])
-		var moveNodes int
-		if moves[i].GetFrom() == E8 && moves[i].GetTo() == C8 {
-			moveNodes = board.divide(depth - 1)
-		} else {
-			moveNodes = board.perft(depth-1, false)
-		}
-		fmt.Println(moveNodes)
-		totalNodes += moveNodes
-		board.UndoMove(moves[i])
+		nodes += board.perft(depth - 1)
+		board.UndoMove(move)
 	}
 
-	return totalNodes
+	return nodes
 }
@@ -0,0 +1,44 @@
+package board
+
+// Room for every move GenMoves can give in one position
+// 218 is the most any standard position has, but Crazyhouse
+// drops can add up to five for every empty square on top
+const MaxMoves = 1024
+
+// Somewhere for the move generators to put moves, owned by the caller
+// so a search can reuse one per ply instead of allocating at every node
+// Scores are left for the caller, to order the moves by
+type MoveList struct {
+	Moves  [MaxMoves]Move
+	Scores [MaxMoves]int32
+	Count  int
+}
+
+func (ml *MoveList) Clear() {
+	ml.Count = 0
+}
+
+func (ml *MoveList) Add(move Move) {
+	ml.Moves[ml.Count] = move
+	ml.Count++
+}
+
+// The moves in the list, sharing its storage
+func (ml *MoveList) Slice() []Move {
+	return ml.Moves[:ml.Count]
+}
+
+// Swaps two moves along with their scores
+func (ml *MoveList) Swap(i, j int) {
+	ml.Moves[i], ml.Moves[j] = ml.Moves[j], ml.Moves[i]
+	ml.Scores[i], ml.Scores[j] = ml.Scores[j], ml.Scores[i]
+}
+
+func (ml *MoveList) Contains(move Move) bool {
+	for _, listed := range ml.Slice() {
+		if listed == move {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package board
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How Render draws a board
+// The zero value is the plain ASCII grid that String gives
+type RenderOptions struct {
+	// Chess symbols instead of piece letters
+	Unicode bool
+	// ANSI background colors for the squares and highlights,
+	// instead of marking highlights with different brackets
+	Color bool
+	// Draws the board with black's side at the bottom
+	FromBlack bool
+	// Highlights the squares the last move came from and went to
+	LastMove Move
+	// Highlights the king of the side to move if it's in check
+	ShowCheck bool
+	// Marks every square in the mask, for looking at attack maps and the like
+	Overlay Bitboard
+}
+
+type squareHighlight uint8
+
+const (
+	noHighlight = squareHighlight(iota)
+	overlayHighlight
+	lastMoveHighlight
+	checkHighlight
+)
+
+// Brackets around each square when there's no color to highlight with
+var highlightBrackets = [...]string{"[]", "{}", "()", "<>"}
+
+// 256 color backgrounds for light and dark squares, by highlight
+var highlightColors = [...][2]int{
+	{223, 173},
+	{152, 67},
+	{186, 143},
+	{203, 160},
+}
+
+const (
+	ansiReset      = "\033[0m"
+	ansiWhitePiece = "\033[1;97m"
+	ansiBlackPiece = "\033[1;30m"
+)
+
+var unicodePieces = [2][King + 1]string{
+	{" ", "♙", "♘", "♗", "♖", "♕", "♔"},
+	{" ", "♟", "♞", "♝", "♜", "♛", "♚"},
+}
+
+// Draws the board as a grid with rank and file labels
+func (board *Board) Render(opts RenderOptions) string {
+	checkSq := NoSq
+	if opts.ShowCheck && board.variant != Antichess {
+		opponent := (board.whoseTurn + 1) % 2
+		kingSq, ok := board.KingSquare(board.whoseTurn)
+		if ok && board.AttackersTo(kingSq, opponent) > 0 {
+			checkSq = SquareOrNone(kingSq)
+		}
+	}
+
+	out := renderGrid(opts, func(sq Square) (string, squareHighlight) {
+		piece := board.pieces[sq]
+		color := White
+		if board.colorBitboards[Black].QuerySquare(sq) {
+			color = Black
+		}
+
+		glyph := string(pieceLetterFromNum(piece, color))
+		if piece == EmptySquare {
+			glyph = " "
+		} else if opts.Unicode {
+			// Solid symbols read better on colored squares,
+			// with the text color telling the sides apart
+			symbolColor := color
+			if opts.Color {
+				symbolColor = Black
+			}
+			glyph = unicodePieces[symbolColor][piece]
+		}
+		if opts.Color && piece != EmptySquare {
+			pieceColor := ansiWhitePiece
+			if color == Black {
+				pieceColor = ansiBlackPiece
+			}
+			glyph = pieceColor + glyph
+		}
+
+		highlight := noHighlight
+		switch {
+		case SquareOrNone(sq) == checkSq:
+			highlight = checkHighlight
+		case opts.LastMove != NullMove &&
+			(sq == opts.LastMove.GetTo() || (sq == opts.LastMove.GetFrom() && !opts.LastMove.IsDrop())):
+			highlight = lastMoveHighlight
+		case opts.Overlay.QuerySquare(sq):
+			highlight = overlayHighlight
+		}
+		return glyph, highlight
+	})
+
+	if board.variant == Crazyhouse {
+		out += fmt.Sprintf("\n  [%s]", board.pocketString())
+	}
+	return out
+}
+
+// Draws a mask on an empty board, the same way Render draws an overlay
+func RenderBitboard(bb Bitboard, opts RenderOptions) string {
+	opts.Overlay = bb
+	return renderGrid(opts, func(sq Square) (string, squareHighlight) {
+		if bb.QuerySquare(sq) {
+			return " ", overlayHighlight
+		}
+		return " ", noHighlight
+	})
+}
+
+// Lays out the squares rank by rank from the viewer's side of the board,
+// with square giving what to draw on each one
+func renderGrid(opts RenderOptions, square func(Square) (string, squareHighlight)) string {
+	var sb strings.Builder
+	for i := 0; i < 8; i++ {
+		rank := 7 - i
+		if opts.FromBlack {
+			rank = i
+		}
+		fmt.Fprintf(&sb, "%d ", rank+1)
+		for j := 0; j < 8; j++ {
+			file := j
+			if opts.FromBlack {
+				file = 7 - j
+			}
+			sq := ConvertRankFile(uint8(rank), uint8(file))
+			glyph, highlight := square(sq)
+
+			if opts.Color {
+				shade := 0
+				// a1 is a dark square
+				if (rank+file)%2 == 0 {
+					shade = 1
+				}
+				fmt.Fprintf(&sb, "\033[48;5;%dm %s %s",
+					highlightColors[highlight][shade], glyph, ansiReset)
+			} else {
+				brackets := highlightBrackets[highlight]
+				fmt.Fprintf(&sb, "%c%s%c", brackets[0], glyph, brackets[1])
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	sb.WriteString(" ")
+	for j := 0; j < 8; j++ {
+		file := j
+		if opts.FromBlack {
+			file = 7 - j
+		}
+		fmt.Fprintf(&sb, "  %c", 'A'+file)
+	}
+	sb.WriteString(" ")
+	return sb.String()
+}
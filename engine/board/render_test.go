@@ -0,0 +1,93 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPlain(t *testing.T) {
+	SetupTables()
+	board := StartPos()
+	expected := "8 [r][n][b][q][k][b][n][r]\n" +
+		"7 [p][p][p][p][p][p][p][p]\n" +
+		"6 [ ][ ][ ][ ][ ][ ][ ][ ]\n" +
+		"5 [ ][ ][ ][ ][ ][ ][ ][ ]\n" +
+		"4 [ ][ ][ ][ ][ ][ ][ ][ ]\n" +
+		"3 [ ][ ][ ][ ][ ][ ][ ][ ]\n" +
+		"2 [P][P][P][P][P][P][P][P]\n" +
+		"1 [R][N][B][Q][K][B][N][R]\n" +
+		"   A  B  C  D  E  F  G  H "
+	if out := board.String(); out != expected {
+		t.Errorf("String gave\n%s", out)
+	}
+
+	flipped := board.Render(RenderOptions{FromBlack: true})
+	lines := strings.Split(flipped, "\n")
+	if lines[0] != "1 [R][N][B][K][Q][B][N][R]" || lines[8] != "   H  G  F  E  D  C  B  A " {
+		t.Errorf("From black's side gave\n%s", flipped)
+	}
+}
+
+func TestRenderHighlights(t *testing.T) {
+	SetupTables()
+	// Fool's mate, just after Qh4#
+	board := FromFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	out := board.Render(RenderOptions{
+		LastMove:  NewMove(D8, H4, NoFlag),
+		ShowCheck: true,
+		Overlay:   Bitboard(1<<F2 | 1<<E3),
+	})
+	lines := strings.Split(out, "\n")
+	var tests = []struct {
+		name     string
+		line     int
+		expected string
+	}{
+		{"Last move", 0, "8 [r][n][b]( )[k][b][n][r]"},
+		{"Last move and overlay", 4, "4 [ ][ ][ ][ ][ ][ ][P](q)"},
+		{"Overlay", 5, "3 [ ][ ][ ][ ]{ }[P][ ][ ]"},
+		{"Check", 7, "1 [R][N][B][Q]<K>[B][N][R]"},
+	}
+	for _, tt := range tests {
+		if lines[tt.line] != tt.expected {
+			t.Errorf("%s: %s instead of %s", tt.name, lines[tt.line], tt.expected)
+		}
+	}
+
+	// Nothing is in check in Antichess
+	antichess, _ := ParseVariantFEN(board.FEN(), Antichess)
+	if strings.Contains(antichess.Render(RenderOptions{ShowCheck: true}), "<") {
+		t.Errorf("Check highlighted in Antichess")
+	}
+}
+
+func TestRenderUnicodeAndColor(t *testing.T) {
+	SetupTables()
+	board := StartPos()
+	unicode := board.Render(RenderOptions{Unicode: true})
+	if !strings.HasPrefix(unicode, "8 [♜][♞][♝][♛][♚][♝][♞][♜]") ||
+		!strings.Contains(unicode, "1 [♖][♘][♗][♕][♔][♗][♘][♖]") {
+		t.Errorf("Unicode gave\n%s", unicode)
+	}
+
+	colored := board.Render(RenderOptions{Color: true, Unicode: true})
+	if strings.ContainsAny(colored, "]♔") {
+		t.Errorf("Colored board still has brackets or outlined pieces")
+	}
+	// a8 is a light square and b8 is dark
+	if !strings.HasPrefix(colored, "8 \033[48;5;223m") ||
+		strings.Count(colored, "\033[48;5;223m") != 32 || strings.Count(colored, "\033[48;5;173m") != 32 {
+		t.Errorf("Colored board has the wrong squares")
+	}
+	if strings.Count(colored, ansiWhitePiece) != 16 || strings.Count(colored, ansiBlackPiece) != 16 {
+		t.Errorf("Colored board has the wrong pieces")
+	}
+}
+
+func TestRenderBitboard(t *testing.T) {
+	out := RenderBitboard(Bitboard(1<<A1|1<<H8), RenderOptions{})
+	lines := strings.Split(out, "\n")
+	if lines[0] != "8 [ ][ ][ ][ ][ ][ ][ ]{ }" || lines[7] != "1 { }[ ][ ][ ][ ][ ][ ][ ]" {
+		t.Errorf("RenderBitboard gave\n%s", out)
+	}
+}
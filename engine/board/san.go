@@ -0,0 +1,222 @@
+package board
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Formats a legal move in Standard Algebraic Notation (e.g. Nbd7, exd5, O-O, e8=Q+, N@f3)
+func (board *Board) MoveToSAN(move Move) string {
+	var legalMoves MoveList
+	board.GenLegalMoves(&legalMoves, false)
+
+	from := move.GetFrom()
+	to := move.GetTo()
+	piece := board.pieces[from]
+
+	var sb strings.Builder
+	switch {
+	case move.IsDrop():
+		sb.WriteString(move.String())
+	case piece == King && move.GetFlag() == Castle:
+		sb.WriteString("O-O")
+	case piece == King && move.GetFlag() == QueenCastle:
+		sb.WriteString("O-O-O")
+	case piece == Pawn:
+		if move.HasFlag(Capture) {
+			sb.WriteString(fileName(from))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(squareName(to))
+		if move.HasFlag(Promotion) {
+			sb.WriteByte('=')
+			sb.WriteByte(pieceLetterFromNum(promotionPiece(move), White))
+		}
+	default:
+		sb.WriteByte(pieceLetterFromNum(piece, White))
+		sb.WriteString(board.sanDisambiguation(move, legalMoves.Slice()))
+		if move.HasFlag(Capture) {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(squareName(to))
+	}
+
+	// Check and checkmate suffixes
+	if board.MakeMove(move) {
+		board.handleCheck()
+		if board.inCheck {
+			var replies MoveList
+			if board.GenLegalMoves(&replies, false); replies.Count == 0 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('+')
+			}
+		}
+		board.UndoMove(move)
+	}
+
+	return sb.String()
+}
+
+// The from file and/or rank needed to tell a piece move apart from
+// moves by the same type of piece to the same square
+func (board *Board) sanDisambiguation(move Move, legalMoves []Move) string {
+	from := move.GetFrom()
+	piece := board.pieces[from]
+
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range legalMoves {
+		otherFrom := other.GetFrom()
+		if other.GetTo() != move.GetTo() || otherFrom == from ||
+			board.pieces[otherFrom] != piece {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || otherFrom%8 == from%8
+		sameRank = sameRank || otherFrom/8 == from/8
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return fileName(from)
+	case !sameRank:
+		return fmt.Sprint(from/8 + 1)
+	default:
+		return squareName(from)
+	}
+}
+
+func promotionPiece(move Move) Piece {
+	if move&kingPromotion > 0 {
+		return King
+	}
+	// The last 2 bits of the flag are the promotion piece - 2
+	return Piece(2 + (move.GetFlag() & 0b11))
+}
+
+// Parses a move in Standard Algebraic Notation, returning an error
+// if it isn't legal or could mean more than one move
+// Check/mate suffixes and annotations like ! and ? are ignored
+func (board *Board) ParseSAN(san string) (Move, error) {
+	var legalMoveList MoveList
+	board.GenLegalMoves(&legalMoveList, false)
+	legalMoves := legalMoveList.Slice()
+
+	text := strings.TrimRight(san, "+#!?")
+	switch text {
+	case "O-O", "0-0":
+		return findCastle(san, legalMoves, Castle)
+	case "O-O-O", "0-0-0":
+		return findCastle(san, legalMoves, QueenCastle)
+	}
+	if pieceString, squareString, isDrop := strings.Cut(text, "@"); isDrop {
+		return findDrop(san, legalMoves, pieceString, squareString)
+	}
+
+	piece := Pawn
+	if len(text) > 0 && strings.ContainsRune("NBRQK", rune(text[0])) {
+		piece = pieceNumFromLetter(rune(text[0] + 'a' - 'A'))
+		text = text[1:]
+	}
+
+	// Promotion piece, either as e8=Q or e8Q
+	promoPiece := EmptySquare
+	if piece == Pawn && len(text) > 0 {
+		if letter := text[len(text)-1]; strings.ContainsRune("NBRQKnbrqk", rune(letter)) {
+			promoPiece = pieceNumFromLetter(rune(strings.ToLower(string(letter))[0]))
+			text = strings.TrimSuffix(text[:len(text)-1], "=")
+		}
+	}
+
+	if len(text) < 2 {
+		return NullMove, fmt.Errorf("invalid SAN \"%s\"", san)
+	}
+	to, ok := parseSquare(text[len(text)-2:])
+	if !ok {
+		return NullMove, fmt.Errorf("invalid SAN \"%s\": bad destination square", san)
+	}
+
+	// Whatever is left is disambiguation, maybe followed by a capture
+	fromFile, fromRank := -1, -1
+	for _, char := range strings.TrimSuffix(text[:len(text)-2], "x") {
+		switch {
+		case char >= 'a' && char <= 'h':
+			fromFile = int(char - 'a')
+		case char >= '1' && char <= '8':
+			fromRank = int(char - '1')
+		default:
+			return NullMove, fmt.Errorf("invalid SAN \"%s\"", san)
+		}
+	}
+
+	found := NullMove
+	for _, move := range legalMoves {
+		from := move.GetFrom()
+		if move.GetTo() != to || board.pieces[from] != piece ||
+			(fromFile >= 0 && int(from%8) != fromFile) ||
+			(fromRank >= 0 && int(from/8) != fromRank) {
+			continue
+		}
+		if piece == King && (move.GetFlag() == Castle || move.GetFlag() == QueenCastle) {
+			continue
+		}
+		if move.HasFlag(Promotion) {
+			if promoPiece == EmptySquare || promotionPiece(move) != promoPiece {
+				continue
+			}
+		} else if promoPiece != EmptySquare {
+			continue
+		}
+		if found != NullMove {
+			return NullMove, fmt.Errorf("ambiguous SAN \"%s\"", san)
+		}
+		found = move
+	}
+
+	if found == NullMove {
+		return NullMove, fmt.Errorf("illegal SAN \"%s\"", san)
+	}
+	return found, nil
+}
+
+func findCastle(san string, legalMoves []Move, flag uint8) (Move, error) {
+	for _, move := range legalMoves {
+		if move.GetFlag() == flag {
+			return move, nil
+		}
+	}
+	return NullMove, fmt.Errorf("illegal SAN \"%s\"", san)
+}
+
+// Crazyhouse drops, where a pawn can be written as P@e4 or @e4
+func findDrop(san string, legalMoves []Move, pieceString, squareString string) (Move, error) {
+	piece := Pawn
+	if len(pieceString) == 1 {
+		piece = pieceNumFromLetter(unicode.ToLower(rune(pieceString[0])))
+	}
+	to, ok := parseSquare(squareString)
+	if len(pieceString) > 1 || piece == EmptySquare || !ok {
+		return NullMove, fmt.Errorf("invalid SAN \"%s\"", san)
+	}
+	drop := NewDrop(piece, to)
+	for _, move := range legalMoves {
+		if move == drop {
+			return move, nil
+		}
+	}
+	return NullMove, fmt.Errorf("illegal SAN \"%s\"", san)
+}
+
+func parseSquare(squareString string) (Square, bool) {
+	if len(squareString) != 2 ||
+		squareString[0] < 'a' || squareString[0] > 'h' ||
+		squareString[1] < '1' || squareString[1] > '8' {
+		return 0, false
+	}
+	file := uint8(squareString[0] - 'a')
+	rank := uint8(squareString[1] - '1')
+	return ConvertRankFile(rank, file), true
+}
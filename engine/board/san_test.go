@@ -0,0 +1,148 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+// A few well known games, written exactly as they appear in the record
+var sanGames = []struct {
+	name  string
+	moves string
+}{
+	{
+		"Opera Game (Morphy vs. Duke of Brunswick and Count Isouard, 1858)",
+		"e4 e5 Nf3 d6 d4 Bg4 dxe5 Bxf3 Qxf3 dxe5 Bc4 Nf6 Qb3 Qe7 Nc3 c6 " +
+			"Bg5 b5 Nxb5 cxb5 Bxb5+ Nbd7 O-O-O Rd8 Rxd7 Rxd7 Rd1 Qe6 " +
+			"Bxd7+ Nxd7 Qb8+ Nxb8 Rd8#",
+	},
+	{
+		"Immortal Game (Anderssen vs. Kieseritzky, 1851)",
+		"e4 e5 f4 exf4 Bc4 Qh4+ Kf1 b5 Bxb5 Nf6 Nf3 Qh6 d3 Nh5 Nh4 Qg5 " +
+			"Nf5 c6 g4 Nf6 Rg1 cxb5 h4 Qg6 h5 Qg5 Qf3 Ng8 Bxf4 Qf6 Nc3 Bc5 " +
+			"Nd5 Qxb2 Bd6 Bxg1 e5 Qxa1+ Ke2 Na6 Nxg7+ Kd8 Qf6+ Nxf6 Be7#",
+	},
+	{
+		"Evergreen Game (Anderssen vs. Dufresne, 1852)",
+		"e4 e5 Nf3 Nc6 Bc4 Bc5 b4 Bxb4 c3 Ba5 d4 exd4 O-O d3 Qb3 Qf6 " +
+			"e5 Qg6 Re1 Nge7 Ba3 b5 Qxb5 Rb8 Qa4 Bb6 Nbd2 Bb7 Ne4 Qf5 " +
+			"Bxd3 Qh5 Nf6+ gxf6 exf6 Rg8 Rad1 Qxf3 Rxe7+ Nxe7 Qxd7+ Kxd7 " +
+			"Bf5+ Ke8 Bd7+ Kf8 Bxe7#",
+	},
+}
+
+func TestSANGames(t *testing.T) {
+	SetupTables()
+	for _, game := range sanGames {
+		t.Run(game.name, func(t *testing.T) {
+			board := StartPos()
+			for _, san := range strings.Fields(game.moves) {
+				move, err := board.ParseSAN(san)
+				if err != nil {
+					t.Fatalf("Failed to parse %s: %s", san, err)
+				}
+				if formatted := board.MoveToSAN(move); formatted != san {
+					t.Errorf("%s formatted as %s", san, formatted)
+				}
+				board.MakeMove(move)
+			}
+		})
+	}
+}
+
+func TestSANPositions(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name string
+		fen  string
+		move Move
+		san  string
+	}{
+		{
+			"file and rank disambiguation",
+			"2k5/8/8/8/4Q2Q/8/8/K6Q w - - 0 1",
+			NewMove(H4, E1, NoFlag),
+			"Qh4e1",
+		},
+		{
+			"rank disambiguation",
+			"2k5/8/8/8/4Q2Q/8/8/K6Q w - - 0 1",
+			NewMove(H1, H2, NoFlag),
+			"Q1h2",
+		},
+		{
+			"file disambiguation",
+			"2k5/8/8/8/4Q2Q/8/8/K6Q w - - 0 1",
+			NewMove(E4, E1, NoFlag),
+			"Qee1",
+		},
+		{
+			"capturing promotion with check",
+			"3r3k/4P3/8/8/8/8/8/K7 w - - 0 1",
+			NewMove(E7, D8, QueenPromo|Capture),
+			"exd8=Q+",
+		},
+		{
+			"underpromotion",
+			"7k/4P3/8/8/8/8/8/K7 w - - 0 1",
+			NewMove(E7, E8, KnightPromo),
+			"e8=N",
+		},
+		{
+			"en passant",
+			"7k/8/8/3pP3/8/8/8/K7 w - d6 0 2",
+			NewMove(E5, D6, EnPassant),
+			"exd6",
+		},
+		{
+			"kingside castle",
+			"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			NewMove(E8, G8, Castle),
+			"O-O",
+		},
+		{
+			"pinned knight doesn't need disambiguating",
+			"4k3/4r3/8/8/8/2N1N3/8/4K3 w - - 0 1",
+			NewMove(C3, D5, NoFlag),
+			"Nd5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := FromFEN(tt.fen)
+			if san := board.MoveToSAN(tt.move); san != tt.san {
+				t.Errorf("%s formatted as %s instead of %s", tt.move, san, tt.san)
+			}
+			move, err := board.ParseSAN(tt.san)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %s", tt.san, err)
+			}
+			if move != tt.move {
+				t.Errorf("%s parsed as %s instead of %s", tt.san, move, tt.move)
+			}
+		})
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name string
+		fen  string
+		san  string
+	}{
+		{"illegal move", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e5"},
+		{"ambiguous move", "2k5/8/8/8/4Q2Q/8/8/K6Q w - - 0 1", "Qe1"},
+		{"garbage", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "hello"},
+		{"missing promotion", "7k/4P3/8/8/8/8/8/K7 w - - 0 1", "e8"},
+		{"castling through pieces", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "O-O"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := FromFEN(tt.fen)
+			if move, err := board.ParseSAN(tt.san); err == nil {
+				t.Errorf("Parsed %s as %s without an error", tt.san, move)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package board
+
+// Piece values used for exchanges, in centipawns
+var SEEPieceValues = [King + 1]int{0, 100, 320, 330, 500, 900, 20000}
+
+// Static Exchange Evaluation: how much material the side to move comes out
+// ahead after the move, if both sides keep recapturing on the destination
+// square with their least valuable piece for as long as it pays off
+// Attackers behind other attackers (x-rays) join in as the ones in front
+// are used up, but pins and checks are ignored
+func (board *Board) SEE(move Move) int {
+	flag := move.GetFlag()
+	if flag == Castle || flag == QueenCastle {
+		return 0
+	}
+	from := move.GetFrom()
+	to := move.GetTo()
+
+	occupied := board.colorBitboards[White] | board.colorBitboards[Black]
+	var gain [32]int
+
+	// What's on the square after the move, and what it's worth
+	onSquare := board.pieces[from]
+	if flag == EnPassant {
+		gain[0] = SEEPieceValues[Pawn]
+		occupied.ClearSquare(enPassantCaptureSq(to, board.whoseTurn))
+	} else if move.HasFlag(Capture) {
+		gain[0] = SEEPieceValues[board.pieces[to]]
+	}
+	if move.HasFlag(Promotion) {
+		onSquare = promotionPiece(move)
+		gain[0] += SEEPieceValues[onSquare] - SEEPieceValues[Pawn]
+	}
+	occupied.ClearSquare(from)
+
+	attackers := board.attackersTo(to, occupied) & occupied
+	side := (board.whoseTurn + 1) % 2
+	depth := 0
+	for {
+		sideAttackers := attackers & board.colorBitboards[side]
+		if sideAttackers == 0 {
+			break
+		}
+		attackerSq, attacker := board.leastValuableAttacker(sideAttackers)
+
+		depth++
+		gain[depth] = SEEPieceValues[onSquare] - gain[depth-1]
+		onSquare = attacker
+
+		// Anything lined up behind the attacker can now see the square
+		occupied.ClearSquare(attackerSq)
+		attackers = board.attackersTo(to, occupied) & occupied
+		side = (side + 1) % 2
+	}
+
+	// Either side can stop recapturing whenever it would lose out
+	for ; depth > 0; depth-- {
+		gain[depth-1] = -max(-gain[depth-1], gain[depth])
+	}
+	return gain[0]
+}
+
+// Whether the move's exchange comes out at or above the threshold,
+// e.g. SEEGreaterOrEqual(move, 0) for captures that don't lose material
+func (board *Board) SEEGreaterOrEqual(move Move, threshold int) bool {
+	return board.SEE(move) >= threshold
+}
+
+func (board *Board) leastValuableAttacker(attackers Bitboard) (Square, Piece) {
+	for piece := Pawn; piece <= King; piece++ {
+		if pieceAttackers := attackers & board.pieceBitboards[piece]; pieceAttackers > 0 {
+			return pieceAttackers.PopLSB(), piece
+		}
+	}
+	return 0, EmptySquare
+}
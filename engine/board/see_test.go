@@ -0,0 +1,51 @@
+package board
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		move     string
+		expected int
+	}{
+		{"Free pawn", "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		{"Losing knight", "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -220},
+		{"X-ray defender", "3q1k2/3r4/8/8/3p4/8/3R4/3RK3 w - - 0 1", "d2d4", -400},
+		{"X-ray attacker", "3r1k2/8/8/8/3p4/8/3R4/3QK3 w - - 0 1", "d2d4", 100},
+		{"Pawn takes defended queen", "4k3/2n5/8/3q4/4P3/8/8/4K3 w - - 0 1", "e4d5", 800},
+		{"Quiet move into a pawn", "4k3/8/4p3/8/3N4/8/8/4K3 w - - 0 1", "d4f5", -320},
+		{"Safe quiet move", "4k3/8/8/8/3N4/8/8/4K3 w - - 0 1", "d4f5", 0},
+		{"En passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},
+		{"Capture promotion", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 1300},
+		{"Queen walks into the king", "4k3/8/8/8/8/8/1q6/K7 b - - 0 1", "b2b1", -900},
+		{"Castling", "4k3/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", 0},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		move := board.findUCIMove(tt.move)
+		if move == NullMove {
+			t.Errorf("%s: %s isn't legal", tt.name, tt.move)
+			continue
+		}
+		if see := board.SEE(move); see != tt.expected {
+			t.Errorf("%s: SEE of %s was %d instead of %d", tt.name, tt.move, see, tt.expected)
+		}
+		if !board.SEEGreaterOrEqual(move, tt.expected) ||
+			board.SEEGreaterOrEqual(move, tt.expected+1) {
+			t.Errorf("%s: threshold check disagrees with SEE of %d", tt.name, tt.expected)
+		}
+	}
+}
+
+func (board *Board) findUCIMove(moveString string) Move {
+	var moves MoveList
+	board.GenLegalMoves(&moves, false)
+	for _, move := range moves.Slice() {
+		if board.MoveToUCI(move) == moveString {
+			return move
+		}
+	}
+	return NullMove
+}
@@ -0,0 +1,141 @@
+package board
+
+// How a game stands, from the point of view of the side to move
+type Status uint8
+
+const (
+	Ongoing = Status(iota)
+	Checkmate
+	Stalemate
+	ThreefoldRepetition
+	FiftyMoveRule
+	InsufficientMaterial
+	// The side to move lost to the variant's rules
+	ThirdCheck
+	KingInCenter
+	// The side to move won Antichess, by having no pieces or no moves left
+	OutOfPieces
+	OutOfMoves
+)
+
+func (status Status) String() string {
+	switch status {
+	case Checkmate:
+		return "checkmate"
+	case Stalemate:
+		return "stalemate"
+	case ThreefoldRepetition:
+		return "threefold repetition"
+	case FiftyMoveRule:
+		return "fifty-move rule"
+	case InsufficientMaterial:
+		return "insufficient material"
+	case ThirdCheck:
+		return "third check"
+	case KingInCenter:
+		return "king of the hill"
+	case OutOfPieces:
+		return "out of pieces"
+	case OutOfMoves:
+		return "out of moves"
+	default:
+		return "ongoing"
+	}
+}
+
+func (status Status) IsDraw() bool {
+	switch status {
+	case Stalemate, ThreefoldRepetition, FiftyMoveRule, InsufficientMaterial:
+		return true
+	}
+	return false
+}
+
+// Whether the side to move has won
+func (status Status) IsWin() bool {
+	return status == OutOfPieces || status == OutOfMoves
+}
+
+// Whether the side to move has lost
+func (status Status) IsLoss() bool {
+	switch status {
+	case Checkmate, ThirdCheck, KingInCenter:
+		return true
+	}
+	return false
+}
+
+// Works out whether the game is over, and how
+// Checkmate takes priority over the fifty-move rule, since a mate
+// on the hundredth half move still counts
+func (board *Board) Status() Status {
+	if status := board.VariantStatus(); status != Ongoing {
+		return status
+	}
+	board.handleCheck()
+	var legalMoves MoveList
+	if board.GenLegalMoves(&legalMoves, false); legalMoves.Count == 0 {
+		return board.NoMovesStatus()
+	}
+	switch {
+	case board.InsufficientMaterial():
+		return InsufficientMaterial
+	case board.halfMoveClock >= 100:
+		return FiftyMoveRule
+	case board.RepetitionCount() >= 3:
+		return ThreefoldRepetition
+	}
+	return Ongoing
+}
+
+// A quick check for draws that doesn't need to generate moves
+// Any repetition counts, since a search can assume it'll be repeated again
+func (board *Board) IsDrawn() bool {
+	return board.halfMoveClock >= 100 || board.IsRepetition() ||
+		board.InsufficientMaterial()
+}
+
+// Whether this position has come up before since the last capture, pawn move
+// or null move
+func (board *Board) IsRepetition() bool {
+	return board.RepetitionCount() > 1
+}
+
+// How many times this position has been reached, including now
+func (board *Board) RepetitionCount() int {
+	count := 1
+	start := max(board.halfMoves-board.halfMoveClock, board.nullMovePly)
+	// Positions with the other side to move can't match
+	for i := board.halfMoves - 2; i >= start; i -= 2 {
+		if board.PosAtNthPly(i) == board.hash {
+			count++
+		}
+	}
+	return count
+}
+
+// Neither side can possibly checkmate: bare kings, a single minor piece,
+// or only bishops that all sit on the same color of square
+// Variants with other ways to win only count it when those are out too
+func (board *Board) InsufficientMaterial() bool {
+	switch board.variant {
+	// Captured pieces come back in Crazyhouse, nobody needs to mate
+	// in Antichess, and any king can walk to the hill
+	case Crazyhouse, Antichess, KingOfTheHill:
+		return false
+	// Every piece but a king can give check
+	case ThreeCheck:
+		return board.colorBitboards[White]|board.colorBitboards[Black] == board.pieceBitboards[King]
+	}
+	if board.pieceBitboards[Pawn]|board.pieceBitboards[Rook]|
+		board.pieceBitboards[Queen] > 0 {
+		return false
+	}
+	knights := board.pieceBitboards[Knight]
+	bishops := board.pieceBitboards[Bishop]
+	if (knights | bishops).PopCount() <= 1 {
+		return true
+	}
+	const lightSquares = Bitboard(0x55AA55AA55AA55AA)
+	return knights == 0 && (bishops&lightSquares == 0 || bishops&^lightSquares == 0)
+}
@@ -0,0 +1,61 @@
+package board
+
+import "testing"
+
+func TestStatus(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		expected Status
+	}{
+		{"Starting pos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", Ongoing},
+		{"Fool's mate", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", Checkmate},
+		{"Stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", Stalemate},
+		{"Fifty moves", "7k/8/6K1/8/8/8/8/R7 w - - 100 80", FiftyMoveRule},
+		{"Mate on the hundredth half move", "R6k/8/6K1/8/8/8/8/8 b - - 100 80", Checkmate},
+		{"Bare kings", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", InsufficientMaterial},
+		{"Lone knight", "8/8/4k3/8/8/3K4/8/6N1 w - - 0 1", InsufficientMaterial},
+		{"Lone bishop", "8/8/4kb2/8/8/3K4/8/8 w - - 0 1", InsufficientMaterial},
+		{"Same colored bishops", "8/8/4k1b1/8/8/3K4/8/5B2 w - - 0 1", InsufficientMaterial},
+		{"Opposite colored bishops", "8/8/4kb2/8/8/3K4/8/5B2 w - - 0 1", Ongoing},
+		{"Two knights", "8/8/4k3/8/8/3K4/8/5NN1 w - - 0 1", Ongoing},
+		{"Lone pawn", "8/8/4k3/8/8/3K4/4P3/8 w - - 0 1", Ongoing},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		if status := board.Status(); status != tt.expected {
+			t.Errorf("%s returned %s instead of %s", tt.name, status, tt.expected)
+		}
+	}
+}
+
+func TestThreefoldRepetition(t *testing.T) {
+	SetupTables()
+	board := StartPos()
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+
+	for _, move := range shuffle {
+		board.UCIMakeMove(move)
+	}
+	// Back to the start position a second time
+	if !board.IsRepetition() || board.RepetitionCount() != 2 {
+		t.Errorf("Start position counted %d times instead of 2", board.RepetitionCount())
+	}
+	if status := board.Status(); status != Ongoing {
+		t.Errorf("Twofold repetition returned %s", status)
+	}
+
+	for _, move := range shuffle {
+		board.UCIMakeMove(move)
+	}
+	if status := board.Status(); status != ThreefoldRepetition {
+		t.Errorf("Threefold repetition returned %s", status)
+	}
+
+	// A pawn move makes the earlier positions unreachable
+	board.UCIMakeMove("e2e4")
+	if board.IsRepetition() {
+		t.Error("Position after a pawn move counted as a repetition")
+	}
+}
@@ -0,0 +1,93 @@
+package board
+
+import "errors"
+
+// Flips the board top to bottom and swaps the colors of every piece,
+// giving the same position from the other side's point of view
+// The history isn't carried over, so the mirror starts a fresh game
+func (board *Board) Mirror() Board {
+	mirrored := board.transformed(func(sq Square) Square { return sq ^ 56 }, true)
+
+	mirrored.whoseTurn = (board.whoseTurn + 1) % 2
+	// White's rights move to black's bits and the other way around
+	mirrored.castleRights = board.castleRights>>2 | (board.castleRights&0b11)<<2
+	for i, rookSq := range board.castleRookSqs {
+		mirrored.castleRookSqs[(i+2)%4] = rookSq ^ 56
+	}
+	mirrored.checksGiven = [2]uint8{board.checksGiven[Black], board.checksGiven[White]}
+	mirrored.pockets = pockets{board.pockets[Black], board.pockets[White]}
+	mirrored.enPassantSq = NoSq
+	if board.enPassantSq != NoSq {
+		mirrored.enPassantSq = board.enPassantSq ^ 56
+	}
+
+	mirrored.finishTransform()
+	return mirrored
+}
+
+// Flips the board left to right, keeping the colors
+// Only works without pawns or castle rights, since neither is symmetric
+func (board *Board) FlipHorizontal() (Board, error) {
+	if board.pieceBitboards[Pawn] > 0 {
+		return Board{}, errors.New("can't flip a position with pawns")
+	}
+	if board.castleRights > 0 {
+		return Board{}, errors.New("can't flip a position with castle rights")
+	}
+
+	flipped := board.transformed(func(sq Square) Square { return sq ^ 7 }, false)
+	flipped.whoseTurn = board.whoseTurn
+	flipped.enPassantSq = NoSq
+
+	flipped.finishTransform()
+	return flipped, nil
+}
+
+// A new board with every piece moved to newSq(sq) and the clocks carried
+// over, leaving the turn, castling and en passant to the caller
+func (board *Board) transformed(newSq func(Square) Square, swapColors bool) Board {
+	out := NewBoard()
+	promoted := board.promoted
+	for promoted > 0 {
+		out.promoted.SetSquare(newSq(promoted.PopLSB()))
+	}
+	for sq := Square(0); sq < 64; sq++ {
+		piece := board.pieces[sq]
+		if piece == EmptySquare {
+			continue
+		}
+		color := White
+		if board.colorBitboards[Black].QuerySquare(sq) {
+			color = Black
+		}
+		if swapColors {
+			color = (color + 1) % 2
+		}
+		to := newSq(sq)
+		out.pieces[to] = piece
+		out.pieceBitboards[piece].SetSquare(to)
+		out.colorBitboards[color].SetSquare(to)
+	}
+	out.chess960 = board.chess960
+	out.variant = board.variant
+	out.checksGiven = board.checksGiven
+	out.pockets = board.pockets
+	out.halfMoveClock = board.halfMoveClock
+	out.fullMoves = board.fullMoves
+	return out
+}
+
+// Works out everything that follows from the pieces and rights,
+// the same way ParseFEN does once it's read the fields
+func (board *Board) finishTransform() {
+	board.halfMoves = (board.fullMoves - 1) * 2
+	if board.whoseTurn == Black {
+		board.halfMoves++
+	}
+
+	board.handleCheck()
+
+	board.genHash()
+	board.historyStart = board.halfMoves
+	board.recordPosition()
+}
@@ -0,0 +1,97 @@
+package board
+
+import (
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		fen      string
+		mirrored string
+	}{
+		{
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			"rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1",
+		},
+		{
+			"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kq - 3 12",
+			"r3k2r/pppbbppp/2n2q1P/1P2p3/3pn3/BN2PNP1/P1PPQPB1/R3K2R b Qk - 3 12",
+		},
+		{
+			// Chess960 castle rights follow the rooks
+			"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9",
+			"bq1bnrkr/npp1p1pp/p2p4/5p2/2P5/3PPN2/PP3PPP/BQNB1RKR b KQkq - 2 9",
+		},
+		{
+			// Black in check
+			"4k3/8/8/8/8/8/8/4RK2 b - - 0 40",
+			"4rk2/8/8/8/8/8/8/4K3 w - - 0 40",
+		},
+	}
+
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		mirrored := board.Mirror()
+		if fen := mirrored.FEN(); fen != tt.mirrored {
+			t.Errorf("%s mirrored to %s instead of %s", tt.fen, fen, tt.mirrored)
+		}
+
+		expected := FromFEN(tt.mirrored)
+		if mirrored.Hash() != expected.Hash() || mirrored.PawnHash() != expected.PawnHash() ||
+			mirrored.MaterialHash() != expected.MaterialHash() {
+			t.Errorf("%s: mirror hashes don't match the mirrored FEN", tt.fen)
+		}
+		if mirrored.InCheck() != board.InCheck() {
+			t.Errorf("%s: mirror has check %t instead of %t", tt.fen, mirrored.InCheck(), board.InCheck())
+		}
+		if mirrored.IsChess960() != board.IsChess960() {
+			t.Errorf("%s: mirror lost Chess960", tt.fen)
+		}
+		for depth := 1; depth <= 3; depth++ {
+			if actual, expected := mirrored.perft(depth), board.perft(depth); actual != expected {
+				t.Errorf("%s: mirror has %d nodes at depth %d instead of %d",
+					tt.fen, actual, depth, expected)
+			}
+		}
+
+		back := mirrored.Mirror()
+		if fen := back.FEN(); fen != tt.fen {
+			t.Errorf("%s mirrored twice gave %s", tt.fen, fen)
+		}
+		if back.Hash() != board.Hash() {
+			t.Errorf("%s: mirroring twice changed the hash", tt.fen)
+		}
+	}
+}
+
+func TestFlipHorizontal(t *testing.T) {
+	SetupTables()
+	board := FromFEN("8/8/2k5/5q2/5n2/8/5K2/8 b - - 4 30")
+	flipped, err := board.FlipHorizontal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fen := flipped.FEN(); fen != "8/8/5k2/2q5/2n5/8/2K5/8 b - - 4 30" {
+		t.Errorf("Flipped to %s", fen)
+	}
+	expected := FromFEN(flipped.FEN())
+	if flipped.Hash() != expected.Hash() {
+		t.Errorf("Flipped hash 0x%x doesn't match the FEN's 0x%x", flipped.Hash(), expected.Hash())
+	}
+	for depth := 1; depth <= 3; depth++ {
+		if actual, expected := flipped.perft(depth), board.perft(depth); actual != expected {
+			t.Errorf("Flipped board has %d nodes at depth %d instead of %d", actual, depth, expected)
+		}
+	}
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+	} {
+		board := FromFEN(fen)
+		if _, err := board.FlipHorizontal(); err == nil {
+			t.Errorf("%s flipped without an error", fen)
+		}
+	}
+}
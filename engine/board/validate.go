@@ -0,0 +1,106 @@
+package board
+
+import "fmt"
+
+var colorNames = [2]string{"white", "black"}
+
+// Checks for positions that can't come up in a real game and that move
+// generation isn't built for, like a missing king or a capturable one
+// Returns the first problem found, or nil if there aren't any
+func (board *Board) Validate() error {
+	// Antichess kings are ordinary pieces, so any number of them is fine
+	if board.variant != Antichess {
+		for color := White; color <= Black; color++ {
+			kings := board.pieceBitboards[King] & board.colorBitboards[color]
+			if count := kings.PopCount(); count != 1 {
+				return fmt.Errorf("%s has %d kings instead of 1", colorNames[color], count)
+			}
+		}
+
+		opponent := (board.whoseTurn + 1) % 2
+		kingSq, _ := board.KingSquare(opponent)
+		if board.AttackersTo(kingSq, board.whoseTurn) > 0 {
+			return fmt.Errorf("%s is in check but it's %s's turn",
+				colorNames[opponent], colorNames[board.whoseTurn])
+		}
+	}
+
+	// The side to move can't have won already, since the game would
+	// have ended before its opponent's move
+	switch board.variant {
+	case ThreeCheck:
+		if checks := board.checksGiven[board.whoseTurn]; checks >= checksToWin {
+			return fmt.Errorf("%s has already given %d checks", colorNames[board.whoseTurn], checks)
+		}
+	case KingOfTheHill:
+		if board.pieceBitboards[King]&board.colorBitboards[board.whoseTurn]&centerSquares > 0 {
+			return fmt.Errorf("%s's king is already on the hill", colorNames[board.whoseTurn])
+		}
+	}
+
+	if pawns := board.pieceBitboards[Pawn] & backRanks; pawns > 0 {
+		return fmt.Errorf("pawn on %s", squareName(pawns.PopLSB()))
+	}
+
+	for i := range board.castleRookSqs {
+		if board.castleRights&(1<<i) > 0 && !board.validCastleRight(i) {
+			return fmt.Errorf("castling right '%c' without its king and rook in place", "KQkq"[i])
+		}
+	}
+
+	if board.enPassantSq != NoSq && !board.validEnPassant() {
+		return fmt.Errorf("en passant square %s without a pawn that just moved two squares",
+			squareName(Square(board.enPassantSq)))
+	}
+	return nil
+}
+
+// Takes away castling and en passant rights that the pieces on the board
+// don't back up, which is the most common problem with hand written FENs
+// Anything Validate still finds afterwards can't be fixed this way
+func (board *Board) Sanitize() {
+	changed := false
+	for i := range board.castleRookSqs {
+		if board.castleRights&(1<<i) > 0 && !board.validCastleRight(i) {
+			board.castleRights &^= 1 << i
+			changed = true
+		}
+	}
+	if board.enPassantSq != NoSq && !board.validEnPassant() {
+		board.enPassantSq = NoSq
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	// The position is a different one now as far as the history goes
+	board.genHash()
+	board.historyStart = board.halfMoves
+	board.recordPosition()
+}
+
+// A castle right needs the king on its back rank and the rook
+// on its own square, on the right side of the king
+func (board *Board) validCastleRight(i int) bool {
+	color := i / 2
+	queenside := i%2 == 1
+	kingSq, ok := board.backRankKing(color)
+	rookSq := board.castleRookSqs[i]
+	return ok && board.pieces[rookSq] == Rook &&
+		board.colorBitboards[color].QuerySquare(rookSq) &&
+		rookSq/8 == kingSq/8 && (rookSq%8 < kingSq%8) == queenside
+}
+
+// An en passant square has to be the one an enemy pawn just skipped over,
+// with the square it started from left empty
+func (board *Board) validEnPassant() bool {
+	sq := Square(board.enPassantSq)
+	opponent := (board.whoseTurn + 1) % 2
+	pawnSq := enPassantCaptureSq(sq, board.whoseTurn)
+	startSq := enPassantCaptureSq(sq, opponent)
+	allPieces := board.colorBitboards[White] | board.colorBitboards[Black]
+	return board.pieces[pawnSq] == Pawn &&
+		board.colorBitboards[opponent].QuerySquare(pawnSq) &&
+		!allPieces.QuerySquare(sq) && !allPieces.QuerySquare(startSq)
+}
@@ -0,0 +1,92 @@
+package board
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	SetupTables()
+	var valid = []struct {
+		name    string
+		variant Variant
+		fen     string
+	}{
+		{"Starting pos", Standard, StartFEN},
+		{"En passant", Standard, "rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b KQkq d3 0 2"},
+		{"Chess960", Standard, "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9"},
+		{"In check", Standard, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"},
+		{"Antichess without kings", Antichess, "8/8/8/3q4/8/8/4P3/8 w - - 0 1"},
+		{"Antichess with extra kings", Antichess, "8/8/2k5/3k4/8/8/3KK3/8 b - - 0 1"},
+		{"Three-check lost", ThreeCheck, "4k3/8/8/8/8/8/8/4K2R b - - 0 1 +3+0"},
+		{"King of the Hill lost", KingOfTheHill, "7k/8/8/8/4K3/8/8/8 b - - 0 1"},
+	}
+	for _, tt := range valid {
+		board, err := ParseVariantFEN(tt.fen, tt.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := board.Validate(); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+		}
+	}
+
+	var invalid = []struct {
+		name    string
+		variant Variant
+		fen     string
+	}{
+		{"No white king", Standard, "4k3/8/8/8/8/8/8/8 w - - 0 1"},
+		{"Two black kings", Standard, "3kk3/8/8/8/8/8/8/4K3 w - - 0 1"},
+		{"Pawn on the first rank", Standard, "4k3/8/8/8/8/8/8/P3K3 w - - 0 1"},
+		{"Pawn on the last rank", Standard, "p3k3/8/8/8/8/8/8/4K3 w - - 0 1"},
+		{"Side not to move in check", Standard, "4k3/8/8/8/8/8/8/4K2r b - - 0 1"},
+		{"Castling without a rook", Standard, "4k3/8/8/8/8/8/8/R3K3 w KQ - 0 1"},
+		{"Castling with an enemy rook", Standard, "4k3/8/8/8/8/8/8/r3K2R w KQ - 0 1"},
+		{"En passant without a pawn", Standard, "4k3/8/8/8/8/8/8/4K3 b - e3 0 1"},
+		{"En passant over a piece", Standard, "4k3/8/8/8/4P3/4N3/8/4K3 b - e3 0 1"},
+		{"Three checks already given", ThreeCheck, "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +3+0"},
+		{"King already on the hill", KingOfTheHill, "7k/8/8/8/4K3/8/8/8 w - - 0 1"},
+	}
+	for _, tt := range invalid {
+		board, err := ParseVariantFEN(tt.fen, tt.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := board.Validate(); err == nil {
+			t.Errorf("%s: %s passed", tt.name, tt.fen)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		fen      string
+		expected string
+	}{
+		{"Missing rook", "r3k2r/8/8/8/8/8/8/R3K3 w KQkq - 0 1", "r3k2r/8/8/8/8/8/8/R3K3 w Qkq - 0 1"},
+		{"Rook taken", "r3k2r/8/8/8/8/8/8/R3K2n w KQkq - 0 1", "r3k2r/8/8/8/8/8/8/R3K2n w Qkq - 0 1"},
+		{"No double pawn push", "4k3/8/8/8/8/8/4P3/4K3 b - e3 0 1", "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"},
+		{"Nothing to fix", StartFEN, StartFEN},
+	}
+	for _, tt := range tests {
+		board := FromFEN(tt.fen)
+		board.Sanitize()
+		if fen := board.FEN(); fen != tt.expected {
+			t.Errorf("%s: sanitized to %s instead of %s", tt.name, fen, tt.expected)
+		}
+		if err := board.Validate(); err != nil {
+			t.Errorf("%s: still invalid after sanitizing: %s", tt.name, err)
+		}
+		expected := FromFEN(tt.expected)
+		if board.Hash() != expected.Hash() {
+			t.Errorf("%s: hash doesn't match the sanitized FEN", tt.name)
+		}
+	}
+
+	// Only rights get fixed
+	board := FromFEN("4k3/8/8/8/8/8/8/P3K3 w - - 0 1")
+	board.Sanitize()
+	if board.Validate() == nil {
+		t.Errorf("Sanitizing fixed a pawn on the back rank")
+	}
+}
@@ -0,0 +1,146 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rules that change how a game is won, on top of the usual ones
+type Variant uint8
+
+const (
+	Standard = Variant(iota)
+	// Giving check three times wins
+	ThreeCheck
+	// Getting the king to one of the four center squares wins
+	KingOfTheHill
+	// Captured pieces can be dropped back on the board by the capturer
+	Crazyhouse
+	// Captures are compulsory, the king is just another piece,
+	// and losing every piece wins
+	Antichess
+)
+
+var Variants = []Variant{Standard, ThreeCheck, KingOfTheHill, Crazyhouse, Antichess}
+
+// The names used by the UCI_Variant option
+func (variant Variant) String() string {
+	switch variant {
+	case ThreeCheck:
+		return "3check"
+	case KingOfTheHill:
+		return "kingofthehill"
+	case Crazyhouse:
+		return "crazyhouse"
+	case Antichess:
+		return "antichess"
+	default:
+		return "chess"
+	}
+}
+
+func ParseVariant(name string) (Variant, error) {
+	for _, variant := range Variants {
+		if variant.String() == name {
+			return variant, nil
+		}
+	}
+	if name == "standard" {
+		return Standard, nil
+	}
+	return Standard, fmt.Errorf("unknown variant \"%s\"", name)
+}
+
+const checksToWin = 3
+
+var centerSquares = Bitboard(1<<D4 | 1<<E4 | 1<<D5 | 1<<E5)
+
+func (board *Board) Variant() Variant {
+	return board.variant
+}
+
+// How many times a side has given check, only counted in Three-check
+func (board *Board) ChecksGiven(color int) int {
+	return int(board.checksGiven[color])
+}
+
+// Whether the side to move has already won or lost by the variant's own
+// rules, and how. Ongoing if it hasn't, or if the variant doesn't have any
+func (board *Board) VariantStatus() Status {
+	opponent := (board.whoseTurn + 1) % 2
+	switch board.variant {
+	case ThreeCheck:
+		if board.checksGiven[opponent] >= checksToWin {
+			return ThirdCheck
+		}
+	case KingOfTheHill:
+		if board.pieceBitboards[King]&board.colorBitboards[opponent]&centerSquares > 0 {
+			return KingInCenter
+		}
+	case Antichess:
+		if board.colorBitboards[board.whoseTurn] == 0 {
+			return OutOfPieces
+		}
+	}
+	return Ongoing
+}
+
+// How the game ends when the side to move has no legal moves
+func (board *Board) NoMovesStatus() Status {
+	switch {
+	case board.variant == Antichess:
+		return OutOfMoves
+	case board.inCheck:
+		return Checkmate
+	default:
+		return Stalemate
+	}
+}
+
+// Counts the check the side that just moved gave, if it gave one
+// Expects the turn to be swapped already
+func (board *Board) countCheck() {
+	if board.variant != ThreeCheck {
+		return
+	}
+	board.handleCheck()
+	mover := (board.whoseTurn + 1) % 2
+	// A game that's already won has nothing left to count, and the
+	// counter can't go past the hash keys
+	if !board.inCheck || board.checksGiven[mover] >= checksToWin {
+		return
+	}
+	board.hash ^= zVals.checks[mover][board.checksGiven[mover]]
+	board.checksGiven[mover]++
+	board.hash ^= zVals.checks[mover][board.checksGiven[mover]]
+}
+
+// Pulls the Three-check counters out of the FEN fields, accepting both
+// the checks left after the en passant square ("3+3") and the checks
+// given after the move counters ("+0+0")
+func parseCheckCounters(fen string, fields []string, board *Board) ([]string, error) {
+	for i, field := range fields {
+		if i < 4 || !strings.Contains(field, "+") {
+			continue
+		}
+		given := strings.HasPrefix(field, "+")
+		white, black, ok := strings.Cut(strings.TrimPrefix(field, "+"), "+")
+		whiteCount, whiteErr := strconv.Atoi(white)
+		blackCount, blackErr := strconv.Atoi(black)
+		if !ok || whiteErr != nil || blackErr != nil ||
+			whiteCount < 0 || whiteCount > checksToWin ||
+			blackCount < 0 || blackCount > checksToWin {
+			return fields, fenError(fen, "bad check counters \"%s\"", field)
+		}
+		if given {
+			board.checksGiven = [2]uint8{uint8(whiteCount), uint8(blackCount)}
+		} else {
+			board.checksGiven = [2]uint8{
+				uint8(checksToWin - whiteCount), uint8(checksToWin - blackCount),
+			}
+		}
+		return append(fields[:i:i], fields[i+1:]...), nil
+	}
+	return fields, nil
+}
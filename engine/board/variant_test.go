@@ -0,0 +1,152 @@
+package board
+
+import "testing"
+
+func TestParseVariant(t *testing.T) {
+	for _, variant := range Variants {
+		if parsed, err := ParseVariant(variant.String()); err != nil || parsed != variant {
+			t.Errorf("%s parsed as %s (%v)", variant, parsed, err)
+		}
+	}
+	if _, err := ParseVariant("bughouse"); err == nil {
+		t.Errorf("Parsed an unknown variant")
+	}
+}
+
+func TestVariantPerft(t *testing.T) {
+	SetupTables()
+	var tests = []struct {
+		name     string
+		variant  Variant
+		fen      string
+		expected []int
+	}{
+		// Nothing about the first few moves is different
+		{"Three-check start", ThreeCheck, StartFEN, []int{20, 400, 8902, 197281}},
+		{"King of the Hill start", KingOfTheHill, StartFEN, []int{20, 400, 8902, 197281}},
+		// Two of the king's moves reach the center and end the game
+		{"King of the Hill race", KingOfTheHill, "7k/8/8/8/8/3K4/8/8 w - - 0 1", []int{8, 18}},
+		// Rh8+ is the third check, so black has no replies to it
+		{"Three-check last check", ThreeCheck, "4k3/8/8/8/8/8/8/4K2R w - - 1+3 0 1", []int{14, 60}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := ParseVariantFEN(tt.fen, tt.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, expected := range tt.expected {
+				if actual := board.perft(i + 1); actual != expected {
+					t.Errorf("Depth %d counted %d nodes instead of %d", i+1, actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	SetupTables()
+	board, err := ParseVariantFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1 +2+0", ThreeCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.ChecksGiven(White) != 2 || board.ChecksGiven(Black) != 0 {
+		t.Fatalf("Checks parsed as %d+%d", board.ChecksGiven(White), board.ChecksGiven(Black))
+	}
+	if fen := board.FEN(); fen != "4k3/8/8/8/8/8/8/4K2R w - - 1+3 0 1" {
+		t.Errorf("FEN written as %s", fen)
+	}
+
+	// A quiet move doesn't count
+	board.UCIMakeMove("h1h2")
+	if board.ChecksGiven(White) != 2 || board.Status() != Ongoing {
+		t.Errorf("Quiet move counted as a check")
+	}
+	board.UndoMove(NewMove(H1, H2, NoFlag))
+
+	hash := board.Hash()
+	board.UCIMakeMove("h1h8")
+	if board.ChecksGiven(White) != 3 {
+		t.Errorf("Check wasn't counted")
+	}
+	if status := board.Status(); status != ThirdCheck {
+		t.Errorf("Status is %s instead of third check", status)
+	}
+	var moves MoveList
+	if board.GenLegalMoves(&moves, false); moves.Count != 0 {
+		t.Errorf("%d moves generated after the third check", moves.Count)
+	}
+	fromFEN, _ := ParseVariantFEN(board.FEN(), ThreeCheck)
+	if board.Hash() != fromFEN.Hash() {
+		t.Errorf("Hash 0x%x doesn't match the FEN's 0x%x", board.Hash(), fromFEN.Hash())
+	}
+
+	board.UndoMove(NewMove(H1, H8, NoFlag))
+	if board.ChecksGiven(White) != 2 || board.Hash() != hash {
+		t.Errorf("Undoing the check left %d checks and hash 0x%x", board.ChecksGiven(White), board.Hash())
+	}
+
+	// The same position with other counters is a different position
+	standard := FromFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	if standard.Hash() == board.Hash() {
+		t.Errorf("Check counters aren't part of the hash")
+	}
+
+	// A knight can still give the last check, but bare kings can't
+	board, _ = ParseVariantFEN("8/8/8/8/8/2K5/8/6Nk w - - 2+3 0 1", ThreeCheck)
+	if status := board.Status(); status != Ongoing || board.IsDrawn() {
+		t.Errorf("Status is %s with a lone knight", status)
+	}
+	board, _ = ParseVariantFEN("8/8/8/8/8/2K5/8/7k w - - 2+3 0 1", ThreeCheck)
+	if status := board.Status(); status != InsufficientMaterial {
+		t.Errorf("Status is %s with bare kings", status)
+	}
+
+	// Validate turns this down, but a check on top of the third one
+	// shouldn't break anything either
+	board, _ = ParseVariantFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1 +3+0", ThreeCheck)
+	board.UCIMakeMove("h1h8")
+	fromFEN, _ = ParseVariantFEN(board.FEN(), ThreeCheck)
+	if board.ChecksGiven(White) != 3 || board.Hash() != fromFEN.Hash() {
+		t.Errorf("A fourth check left %d checks and hash 0x%x instead of 0x%x",
+			board.ChecksGiven(White), board.Hash(), fromFEN.Hash())
+	}
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/8/4K2R w - - 4+3 0 1",
+		"4k3/8/8/8/8/8/8/4K2R w - - 0 1 +x+0",
+		"4k3/8/8/8/8/8/8/4K2R w - - 3 0 1",
+	} {
+		if _, err := ParseVariantFEN(fen, ThreeCheck); err == nil {
+			t.Errorf("Parsed \"%s\" without an error", fen)
+		}
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	SetupTables()
+	board, _ := ParseVariantFEN("7k/8/8/8/8/3K4/8/8 w - - 0 1", KingOfTheHill)
+	// Bare kings can still win by reaching the center
+	if status := board.Status(); status != Ongoing || board.IsDrawn() {
+		t.Errorf("Status is %s before reaching the hill", status)
+	}
+	board.UCIMakeMove("d3e4")
+	if status := board.Status(); status != KingInCenter {
+		t.Errorf("Status is %s instead of king of the hill", status)
+	}
+	if status := board.Status(); status.IsDraw() {
+		t.Errorf("%s counted as a draw", status)
+	}
+
+	board, _ = ParseVariantFEN("8/8/8/8/8/2K5/8/6bk w - - 0 1", KingOfTheHill)
+	if status := board.Status(); status != Ongoing || board.IsDrawn() {
+		t.Errorf("Status is %s with a lone bishop", status)
+	}
+
+	// Standard chess doesn't care
+	board = FromFEN("7k/8/8/8/8/3K4/8/8 w - - 0 1")
+	board.UCIMakeMove("d3e4")
+	if status := board.Status(); status != InsufficientMaterial {
+		t.Errorf("Status is %s in standard chess", status)
+	}
+}
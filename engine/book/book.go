@@ -0,0 +1,191 @@
+package book
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+
+	"20hh/engine/board"
+)
+
+// An opening book in Polyglot's .bin format, which is a list of 16 byte
+// entries sorted by the Zobrist key of the position they're for
+// Every number is big endian
+type Book struct {
+	entries []entry
+}
+
+type entry struct {
+	key    uint64
+	move   uint16
+	weight uint16
+	// The other 4 bytes are for learning, which nothing uses
+}
+
+const entrySize = 16
+
+// A move out of the book, and how often it should be played
+// compared to the other moves for the same position
+type Move struct {
+	Move   board.Move
+	Weight uint16
+}
+
+// How to choose between the moves for a position
+type Selection uint8
+
+const (
+	// Plays each move with a chance in proportion to its weight
+	WeightedRandom = Selection(iota)
+	// Always plays the heaviest move
+	BestMove
+)
+
+func Load(path string) (*Book, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	book, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return book, nil
+}
+
+func Read(r io.Reader) (*Book, error) {
+	book := &Book{}
+	var raw [entrySize]byte
+	for {
+		n, err := io.ReadFull(r, raw[:])
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("entry %d is cut short at %d bytes", len(book.entries), n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		book.entries = append(book.entries, entry{
+			key:    binary.BigEndian.Uint64(raw[0:8]),
+			move:   binary.BigEndian.Uint16(raw[8:10]),
+			weight: binary.BigEndian.Uint16(raw[10:12]),
+		})
+	}
+	// Books should already be sorted, but lookups depend on it
+	slices.SortStableFunc(book.entries, func(a, b entry) int {
+		switch {
+		case a.key < b.key:
+			return -1
+		case a.key > b.key:
+			return 1
+		}
+		return 0
+	})
+	return book, nil
+}
+
+// How many entries the book has, across every position
+func (book *Book) Len() int {
+	return len(book.entries)
+}
+
+// The book's legal moves for a position, heaviest first
+// Books only cover standard chess, so other variants never have any
+// Moves with no weight are left out, since they're never meant to be played
+func (book *Book) Moves(b *board.Board) []Move {
+	if b.Variant() != board.Standard {
+		return nil
+	}
+	key := b.Hash()
+	idx, _ := slices.BinarySearchFunc(book.entries, key, func(e entry, key uint64) int {
+		switch {
+		case e.key < key:
+			return -1
+		case e.key > key:
+			return 1
+		}
+		return 0
+	})
+
+	var moves []Move
+	for ; idx < len(book.entries) && book.entries[idx].key == key; idx++ {
+		e := book.entries[idx]
+		if e.weight == 0 {
+			continue
+		}
+		if move, ok := DecodeMove(b, e.move); ok {
+			moves = append(moves, Move{move, e.weight})
+		}
+	}
+	slices.SortStableFunc(moves, func(a, b Move) int {
+		return int(b.Weight) - int(a.Weight)
+	})
+	return moves
+}
+
+// Picks a move for the position, if the book has any
+func (book *Book) Probe(b *board.Board, selection Selection) (board.Move, bool) {
+	moves := book.Moves(b)
+	if len(moves) == 0 {
+		return board.NullMove, false
+	}
+	if selection == BestMove {
+		return moves[0].Move, true
+	}
+
+	total := 0
+	for _, move := range moves {
+		total += int(move.Weight)
+	}
+	// Unlike util's generator this is seeded differently every run,
+	// so a fresh engine doesn't open every game the same way
+	pick := rand.Intn(total)
+	for _, move := range moves {
+		if pick < int(move.Weight) {
+			return move.Move, true
+		}
+		pick -= int(move.Weight)
+	}
+	return moves[0].Move, true
+}
+
+// Polyglot packs a move into 16 bits: to file, to rank, from file,
+// from rank and promotion piece, three bits each from the bottom
+// Castling is written as the king taking its own rook
+// Returns false if the move isn't legal in the position
+func DecodeMove(b *board.Board, encoded uint16) (board.Move, bool) {
+	to := board.Square(encoded & 0x3F)
+	from := board.Square((encoded >> 6) & 0x3F)
+	// None, knight, bishop, rook, queen
+	promotion := (encoded >> 12) & 0x7
+
+	var legalMoves board.MoveList
+	b.GenLegalMoves(&legalMoves, false)
+	for _, move := range legalMoves.Slice() {
+		moveTo := move.GetTo()
+		flag := move.GetFlag()
+		if flag == board.Castle || flag == board.QueenCastle {
+			moveTo = b.CastleRookSquare(move)
+		}
+		if move.GetFrom() != from || moveTo != to {
+			continue
+		}
+		// The bottom 2 bits of a promotion flag are the piece, knight first
+		if move.HasFlag(board.Promotion) {
+			if promotion == 0 || uint16(flag&0b11) != promotion-1 {
+				continue
+			}
+		} else if promotion != 0 {
+			continue
+		}
+		return move, true
+	}
+	return board.NullMove, false
+}
@@ -0,0 +1,148 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"20hh/engine/board"
+)
+
+type testEntry struct {
+	fen    string
+	from   board.Square
+	to     board.Square
+	promo  uint16
+	weight uint16
+}
+
+const castleFEN = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+const promoFEN = "7k/P7/8/8/8/8/8/K7 w - - 0 1"
+
+var testEntries = []testEntry{
+	{board.StartFEN, board.E2, board.E4, 0, 30},
+	{board.StartFEN, board.D2, board.D4, 0, 10},
+	{board.StartFEN, board.G1, board.F3, 0, 0},
+	// Not legal, so it should be skipped
+	{board.StartFEN, board.E2, board.E5, 0, 100},
+	{castleFEN, board.E1, board.H1, 0, 2},
+	{castleFEN, board.E1, board.A1, 0, 1},
+	{promoFEN, board.A7, board.A8, 4, 5},
+	{promoFEN, board.A7, board.A8, 1, 3},
+}
+
+// Writes the entries out the way a .bin file has them
+func writeBook(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	// Backwards, since they should get sorted on load anyway
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		b, err := board.ParseFEN(e.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw [entrySize]byte
+		binary.BigEndian.PutUint64(raw[0:8], b.Hash())
+		binary.BigEndian.PutUint16(raw[8:10], uint16(e.to)|uint16(e.from)<<6|e.promo<<12)
+		binary.BigEndian.PutUint16(raw[10:12], e.weight)
+		buf.Write(raw[:])
+	}
+	return buf.Bytes()
+}
+
+func loadTestBook(t *testing.T) *Book {
+	board.Init()
+	book, err := Read(bytes.NewReader(writeBook(t, testEntries)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Len() != len(testEntries) {
+		t.Fatalf("Loaded %d entries instead of %d", book.Len(), len(testEntries))
+	}
+	return book
+}
+
+func moveStrings(b *board.Board, moves []Move) []string {
+	var strs []string
+	for _, move := range moves {
+		strs = append(strs, b.MoveToUCI(move.Move))
+	}
+	return strs
+}
+
+func TestMoves(t *testing.T) {
+	book := loadTestBook(t)
+	for _, tt := range []struct {
+		fen      string
+		expected []string
+	}{
+		{board.StartFEN, []string{"e2e4", "d2d4"}},
+		{castleFEN, []string{"e1g1", "e1c1"}},
+		{promoFEN, []string{"a7a8q", "a7a8n"}},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", nil},
+	} {
+		b, _ := board.ParseFEN(tt.fen)
+		moves := moveStrings(&b, book.Moves(&b))
+		if len(moves) != len(tt.expected) {
+			t.Errorf("%s: found %v instead of %v", tt.fen, moves, tt.expected)
+			continue
+		}
+		for i := range moves {
+			if moves[i] != tt.expected[i] {
+				t.Errorf("%s: found %v instead of %v", tt.fen, moves, tt.expected)
+				break
+			}
+		}
+	}
+
+	// The Polyglot keys are for standard chess only
+	b, _ := board.ParseVariantFEN(board.StartFEN, board.ThreeCheck)
+	if moves := book.Moves(&b); len(moves) > 0 {
+		t.Errorf("Found %v in a Three-check game", moveStrings(&b, moves))
+	}
+}
+
+func TestCastlingChess960(t *testing.T) {
+	board.Init()
+	b, _ := board.ParseFEN(castleFEN)
+	b.SetChess960(true)
+	move, ok := DecodeMove(&b, uint16(board.H1)|uint16(board.E1)<<6)
+	if !ok || move.GetFlag() != board.Castle {
+		t.Errorf("e1h1 decoded to %s", move)
+	}
+	if move, ok := DecodeMove(&b, uint16(board.G1)|uint16(board.E1)<<6); ok {
+		t.Errorf("e1g1 decoded to %s instead of nothing", move)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	book := loadTestBook(t)
+	b := board.StartPos()
+
+	move, ok := book.Probe(&b, BestMove)
+	if !ok || b.MoveToUCI(move) != "e2e4" {
+		t.Errorf("Best move is %s", move)
+	}
+
+	picked := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		move, _ := book.Probe(&b, WeightedRandom)
+		picked[b.MoveToUCI(move)]++
+	}
+	if len(picked) != 2 || picked["e2e4"] <= picked["d2d4"] || picked["d2d4"] == 0 {
+		t.Errorf("Picked %v", picked)
+	}
+
+	b.UCIMakeMove("e2e4")
+	if move, ok := book.Probe(&b, WeightedRandom); ok {
+		t.Errorf("Found %s after leaving the book", move)
+	}
+}
+
+func TestTruncatedBook(t *testing.T) {
+	board.Init()
+	raw := writeBook(t, testEntries[:2])
+	if _, err := Read(bytes.NewReader(raw[:len(raw)-3])); err == nil {
+		t.Errorf("A cut short entry was read without an error")
+	}
+}
@@ -1,9 +1,13 @@
 package engine
 
 import (
+	"fmt"
+	"io"
 	"time"
 
 	"20hh/engine/board"
+	"20hh/engine/book"
+	"20hh/engine/perft"
 	"20hh/engine/search"
 	"20hh/engine/util"
 )
@@ -15,6 +19,14 @@ type Engine struct {
 	currentBoard board.Board
 	search       search.Searcher
 	ttSizeMb     uint16
+	chess960     bool          // UCI_Chess960
+	variant      board.Variant // UCI_Variant
+	// The move that led to the current position, for highlighting
+	lastMove board.Move
+
+	ownBook       bool       // OwnBook
+	book          *book.Book // BookFile, nil if there isn't one
+	bookSelection book.Selection
 }
 
 func Init() {
@@ -27,22 +39,117 @@ func (engine *Engine) ResetSearch() {
 	engine.search.Reset(engine.ttSizeMb)
 }
 
-func (engine *Engine) GameFromFENString(fen string) {
-	engine.currentBoard = board.FromFEN(fen)
+// Leaves the current game untouched if the FEN is invalid or the position
+// couldn't come up in a game, after dropping any rights it doesn't back up
+func (engine *Engine) GameFromFENString(fen string) error {
+	newBoard, err := board.ParseVariantFEN(fen, engine.variant)
+	if err != nil {
+		return err
+	}
+	newBoard.Sanitize()
+	if err := newBoard.Validate(); err != nil {
+		return fmt.Errorf("illegal position \"%s\": %s", fen, err)
+	}
+	engine.currentBoard = newBoard
+	engine.lastMove = board.NullMove
+	engine.applyChess960()
+	return nil
 }
 
 func (engine *Engine) GameFromStartPos() {
-	engine.currentBoard = board.StartPos()
+	// Every variant starts from the usual position
+	newBoard, err := board.ParseVariantFEN(board.StartFEN, engine.variant)
+	if err != nil {
+		panic(err)
+	}
+	engine.currentBoard = newBoard
+	engine.lastMove = board.NullMove
+	engine.applyChess960()
+}
+
+func (engine *Engine) SetChess960(chess960 bool) {
+	engine.chess960 = chess960
+	engine.applyChess960()
+}
+
+// Takes effect from the next position that's set up
+func (engine *Engine) SetVariant(variant board.Variant) {
+	engine.variant = variant
 }
 
+// Whether GetBestMove plays book moves while there are any
+func (engine *Engine) SetOwnBook(ownBook bool) {
+	engine.ownBook = ownBook
+}
+
+// Keeps the current book if the new one can't be loaded,
+// and drops it for an empty path
+func (engine *Engine) LoadBook(path string) error {
+	if path == "" {
+		engine.book = nil
+		return nil
+	}
+	loaded, err := book.Load(path)
+	if err != nil {
+		return err
+	}
+	engine.book = loaded
+	return nil
+}
+
+func (engine *Engine) SetBookSelection(selection book.Selection) {
+	engine.bookSelection = selection
+}
+
+// A FEN that can only be Chess960 stays that way even with the option off
+func (engine *Engine) applyChess960() {
+	if engine.chess960 {
+		engine.currentBoard.SetChess960(true)
+	}
+}
+
+// Formats a move how UCI expects it for the current game
+func (engine *Engine) MoveToUCI(move board.Move) string {
+	return engine.currentBoard.MoveToUCI(move)
+}
+
+// Plays a move only if it's legal in the current position
 func (engine *Engine) PlayMoveFromUCI(moveString string) {
-	engine.currentBoard.UCIMakeMove(moveString)
+	var legalMoves board.MoveList
+	engine.currentBoard.GenLegalMoves(&legalMoves, false)
+	for _, move := range legalMoves.Slice() {
+		if engine.MoveToUCI(move) == moveString {
+			engine.lastMove = move
+			engine.currentBoard.MakeMove(move)
+			return
+		}
+	}
+	fmt.Printf("info string illegal move \"%s\"\n", moveString)
+}
+
+// Draws the current position with the last move and any check highlighted
+func (engine *Engine) RenderBoard(opts board.RenderOptions) string {
+	opts.LastMove = engine.lastMove
+	opts.ShowCheck = true
+	return engine.currentBoard.Render(opts)
 }
 
 func (engine *Engine) EndSearch() {
 	engine.search.CancelSearch()
 }
 
+// Hash table size used by go perft
+const perftHashMb = 64
+
+// Counts the nodes under each move from the current position and writes them
+// in divide format, followed by the speed
+func (engine *Engine) Perft(depth int, w io.Writer) {
+	result := perft.Divide(&engine.currentBoard, depth, perft.Options{HashMb: perftHashMb})
+	result.Write(w, &engine.currentBoard)
+	fmt.Fprintf(w, "info nodes %d time %d nps %d\n",
+		result.Nodes, result.Elapsed.Milliseconds(), result.NPS())
+}
+
 type SearchOpts struct {
 	timeRemaining int
 	timeInc       int
@@ -53,6 +160,12 @@ type SearchOpts struct {
 func (engine *Engine) GetBestMove(
 	opts SearchOpts, loggingCallback search.LogCallback,
 ) board.Move {
+	if engine.ownBook && engine.book != nil {
+		if move, ok := engine.book.Probe(&engine.currentBoard, engine.bookSelection); ok {
+			return move
+		}
+	}
+
 	moveChan := make(chan board.Move)
 	moveTime := opts.timeRemaining/40 + opts.timeInc/2
 
@@ -0,0 +1,81 @@
+package perft
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A position from a perft EPD suite, with the expected node
+// count at each depth it lists
+type SuitePosition struct {
+	FEN    string
+	Counts map[int]uint64
+	Line   int // Where it came from, for error messages
+}
+
+// The depths with expected counts, shallowest first
+func (position SuitePosition) Depths() []int {
+	depths := make([]int, 0, len(position.Counts))
+	for depth := range position.Counts {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	return depths
+}
+
+// Parses one line of a perft suite, in the usual format of a FEN
+// followed by counts for each depth:
+//
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - ;D1 20 ;D2 400
+func ParseEPD(line string) (SuitePosition, error) {
+	fields := strings.Split(line, ";")
+	position := SuitePosition{
+		FEN:    strings.TrimSpace(fields[0]),
+		Counts: make(map[int]uint64),
+	}
+	if position.FEN == "" {
+		return position, fmt.Errorf("perft EPD \"%s\" has no FEN", line)
+	}
+
+	for _, field := range fields[1:] {
+		depthField, countField, ok := strings.Cut(strings.TrimSpace(field), " ")
+		if !ok || len(depthField) < 2 || depthField[0] != 'D' {
+			return position, fmt.Errorf("perft EPD \"%s\": bad field \"%s\"", line, field)
+		}
+		depth, err := strconv.Atoi(depthField[1:])
+		if err != nil || depth < 0 {
+			return position, fmt.Errorf("perft EPD \"%s\": bad depth \"%s\"", line, depthField)
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(countField), 10, 64)
+		if err != nil {
+			return position, fmt.Errorf("perft EPD \"%s\": bad count \"%s\"", line, countField)
+		}
+		position.Counts[depth] = count
+	}
+	return position, nil
+}
+
+// Reads a whole suite, skipping blank lines and # comments
+func ReadEPD(r io.Reader) ([]SuitePosition, error) {
+	var positions []SuitePosition
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		position, err := ParseEPD(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		position.Line = lineNum
+		positions = append(positions, position)
+	}
+	return positions, scanner.Err()
+}
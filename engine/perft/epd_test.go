@@ -0,0 +1,98 @@
+package perft
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"20hh/engine/board"
+)
+
+// go test ./engine/perft -perft.suite=other.epd -perft.depth=6
+var (
+	suiteFile  = flag.String("perft.suite", "testdata/perftsuite.epd", "perft EPD suite to run")
+	suiteDepth = flag.Int("perft.depth", 4, "deepest depth to check from the suite")
+)
+
+func TestPerftSuite(t *testing.T) {
+	board.Init()
+	file, err := os.Open(*suiteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	suite, err := ReadEPD(file)
+	if err != nil {
+		t.Fatalf("%s: %s", *suiteFile, err)
+	}
+	if len(suite) == 0 {
+		t.Fatalf("%s has no positions", *suiteFile)
+	}
+
+	opts := Options{Threads: 4, HashMb: 16}
+	for _, position := range suite {
+		b, err := board.ParseFEN(position.FEN)
+		if err != nil {
+			t.Errorf("%s:%d: %s", *suiteFile, position.Line, err)
+			continue
+		}
+		for _, depth := range position.Depths() {
+			if depth > *suiteDepth {
+				break
+			}
+			result := Divide(&b, depth, opts)
+			if expected := position.Counts[depth]; result.Nodes != expected {
+				t.Errorf("%s:%d: %s at depth %d counted %d nodes instead of %d",
+					*suiteFile, position.Line, position.FEN, depth, result.Nodes, expected)
+			}
+		}
+	}
+}
+
+func TestParseEPD(t *testing.T) {
+	position, err := ParseEPD("4k3/8/8/8/8/8/8/4K2R w K - 0 1 ;D1 15 ;D2 66 ; D3 1198")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position.FEN != "4k3/8/8/8/8/8/8/4K2R w K - 0 1" {
+		t.Errorf("FEN parsed as \"%s\"", position.FEN)
+	}
+	depths := position.Depths()
+	if len(depths) != 3 || depths[0] != 1 || depths[2] != 3 {
+		t.Errorf("Depths parsed as %v", depths)
+	}
+	if position.Counts[3] != 1198 {
+		t.Errorf("Depth 3 count parsed as %d", position.Counts[3])
+	}
+
+	for _, line := range []string{
+		";D1 20",
+		"8/8/8/8/8/8/8/k6K w - - ;D1",
+		"8/8/8/8/8/8/8/k6K w - - ;P1 3",
+		"8/8/8/8/8/8/8/k6K w - - ;Dx 3",
+		"8/8/8/8/8/8/8/k6K w - - ;D1 -3",
+	} {
+		if _, err := ParseEPD(line); err == nil {
+			t.Errorf("\"%s\" parsed without an error", line)
+		}
+	}
+}
+
+func TestReadEPD(t *testing.T) {
+	suite, err := ReadEPD(strings.NewReader(
+		"# comment\n\n8/8/8/8/8/8/8/k6K w - - ;D1 3\n  \n8/8/8/8/8/8/8/K6k b - - ;D1 3\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suite) != 2 || suite[0].Line != 3 || suite[1].Line != 5 {
+		t.Errorf("Read %+v", suite)
+	}
+
+	if _, err := ReadEPD(strings.NewReader("8/8/8/8/8/8/8/k6K w - - ;D1 3\n;D1 4\n")); err == nil ||
+		!strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Bad line reported as %v", err)
+	}
+}
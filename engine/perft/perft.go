@@ -0,0 +1,149 @@
+package perft
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"20hh/engine/board"
+)
+
+type Options struct {
+	Threads int // Defaults to one per CPU
+	HashMb  int // No hash table if 0
+}
+
+// Nodes under a single root move
+type MoveCount struct {
+	Move  board.Move
+	Nodes uint64
+}
+
+type Result struct {
+	Depth   int
+	Moves   []MoveCount // In the order they were generated
+	Nodes   uint64
+	Elapsed time.Duration
+}
+
+func (result Result) NPS() uint64 {
+	seconds := result.Elapsed.Seconds()
+	if seconds == 0 {
+		return result.Nodes
+	}
+	return uint64(float64(result.Nodes) / seconds)
+}
+
+// Counts the leaf nodes of the legal move tree, on a single thread
+func Perft(b *board.Board, depth int) uint64 {
+	return newCounter(depth, nil).count(b, depth)
+}
+
+// Counts the nodes under each legal root move, spreading
+// the root moves across goroutines
+func Divide(b *board.Board, depth int, opts Options) Result {
+	start := time.Now()
+	result := Result{Depth: depth}
+	if depth <= 0 {
+		result.Nodes = 1
+		result.Elapsed = time.Since(start)
+		return result
+	}
+
+	threads := opts.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	hashTable := newTable(opts.HashMb)
+
+	var moves board.MoveList
+	b.GenLegalMoves(&moves, false)
+	result.Moves = make([]MoveCount, moves.Count)
+	work := make(chan int, moves.Count)
+	for i, move := range moves.Slice() {
+		result.Moves[i].Move = move
+		work <- i
+	}
+	close(work)
+
+	var wg sync.WaitGroup
+	for thread := 0; thread < threads; thread++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each goroutine plays on its own copy of the board
+			local := b.Clone()
+			counter := newCounter(depth-1, hashTable)
+			for i := range work {
+				move := result.Moves[i].Move
+				local.MakeMove(move)
+				result.Moves[i].Nodes = counter.count(&local, depth-1)
+				local.UndoMove(move)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, moveCount := range result.Moves {
+		result.Nodes += moveCount.Nodes
+	}
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// Walks the move tree for one goroutine, with a move list
+// for each depth so nothing is allocated along the way
+type counter struct {
+	hashTable *table
+	moveLists []board.MoveList
+}
+
+func newCounter(depth int, hashTable *table) *counter {
+	return &counter{hashTable, make([]board.MoveList, max(depth, 0)+1)}
+}
+
+func (c *counter) count(b *board.Board, depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	// Checked first so a hit doesn't pay for generating moves
+	if depth > 1 && c.hashTable != nil {
+		if nodes, ok := c.hashTable.get(b.Hash(), depth); ok {
+			return nodes
+		}
+	}
+	moves := &c.moveLists[depth]
+	moves.Clear()
+	b.GenLegalMoves(moves, false)
+	// Leaves don't need to be played out
+	if depth == 1 {
+		return uint64(moves.Count)
+	}
+
+	nodes := uint64(0)
+	for _, move := range moves.Slice() {
+		b.MakeMove(move)
+		nodes += c.count(b, depth-1)
+		b.UndoMove(move)
+	}
+
+	if c.hashTable != nil {
+		c.hashTable.put(b.Hash(), depth, nodes)
+	}
+	return nodes
+}
+
+// Writes the counts in the usual divide format, one "move: nodes" line per
+// root move followed by the total, so the output can be diffed against
+// other engines. Moves are written how b formats them for UCI
+func (result Result) Write(w io.Writer, b *board.Board) error {
+	for _, moveCount := range result.Moves {
+		if _, err := fmt.Fprintf(w, "%s: %d\n", b.MoveToUCI(moveCount.Move), moveCount.Nodes); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nNodes searched: %d\n", result.Nodes)
+	return err
+}
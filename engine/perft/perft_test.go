@@ -0,0 +1,101 @@
+package perft
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"20hh/engine/board"
+)
+
+// https://www.chessprogramming.org/Perft_Results
+var positions = []struct {
+	name     string
+	fen      string
+	depth    int
+	expected uint64
+}{
+	{"Starting pos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 5, 4865609},
+	{"Position 2", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 4, 4085603},
+	{"Position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
+	{"Position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 4, 422333},
+	{"Position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 4, 2103487},
+}
+
+func TestDivide(t *testing.T) {
+	board.Init()
+	for _, tt := range positions {
+		for _, opts := range []Options{
+			{Threads: 1},
+			{Threads: 4},
+			{Threads: 4, HashMb: 1},
+		} {
+			b := board.FromFEN(tt.fen)
+			result := Divide(&b, tt.depth, opts)
+			if result.Nodes != tt.expected {
+				t.Errorf("%s with %+v counted %d nodes instead of %d",
+					tt.name, opts, result.Nodes, tt.expected)
+			}
+
+			sum := uint64(0)
+			for _, moveCount := range result.Moves {
+				sum += moveCount.Nodes
+			}
+			if sum != result.Nodes {
+				t.Errorf("%s with %+v: moves add up to %d, not %d",
+					tt.name, opts, sum, result.Nodes)
+			}
+			if fen := b.FEN(); fen != tt.fen {
+				t.Errorf("%s: board left at %s", tt.name, fen)
+			}
+		}
+	}
+}
+
+func TestPerft(t *testing.T) {
+	board.Init()
+	b := board.StartPos()
+	for depth, expected := range []uint64{1, 20, 400, 8902, 197281} {
+		if nodes := Perft(&b, depth); nodes != expected {
+			t.Errorf("Depth %d counted %d nodes instead of %d", depth, nodes, expected)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	board.Init()
+	b := board.FromFEN("4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	b.SetChess960(true)
+	result := Divide(&b, 1, Options{Threads: 2})
+
+	var out bytes.Buffer
+	if err := result.Write(&out, &b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != len(result.Moves)+3 {
+		t.Fatalf("Output has %d lines:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(out.String(), "e1h1: 1\n") {
+		t.Errorf("Chess960 castling wasn't written as king takes rook:\n%s", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\nNodes searched: 15\n") {
+		t.Errorf("Output doesn't end with the total:\n%s", out.String())
+	}
+}
+
+// Run with -benchmem to see allocations per perft
+func BenchmarkPerft(b *testing.B) {
+	board.Init()
+	for _, tt := range positions[:2] {
+		b.Run(tt.name, func(b *testing.B) {
+			pos := board.FromFEN(tt.fen)
+			b.ReportAllocs()
+			nodes := uint64(0)
+			for i := 0; i < b.N; i++ {
+				nodes += Perft(&pos, tt.depth-1)
+			}
+			b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+		})
+	}
+}
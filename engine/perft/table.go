@@ -0,0 +1,44 @@
+package perft
+
+import (
+	"sync/atomic"
+)
+
+// Node counts for (position, depth) pairs, shared between goroutines
+// without locks: each entry stores its key XORed with its data, so a
+// torn write from two goroutines at once just fails to match
+type table struct {
+	entries []entry
+}
+
+type entry struct {
+	check uint64 // Zobrist hash ^ data
+	data  uint64 // Node count << 8 | depth
+}
+
+const entrySize = 16
+
+func newTable(mbSize int) *table {
+	if mbSize <= 0 {
+		return nil
+	}
+	size := mbSize * 1024 * 1024 / entrySize
+	return &table{make([]entry, size)}
+}
+
+func (t *table) get(hash uint64, depth int) (uint64, bool) {
+	e := &t.entries[hash%uint64(len(t.entries))]
+	data := atomic.LoadUint64(&e.data)
+	check := atomic.LoadUint64(&e.check)
+	if check^data != hash || data&0xff != uint64(depth) {
+		return 0, false
+	}
+	return data >> 8, true
+}
+
+func (t *table) put(hash uint64, depth int, nodes uint64) {
+	e := &t.entries[hash%uint64(len(t.entries))]
+	data := nodes<<8 | uint64(depth)
+	atomic.StoreUint64(&e.data, data)
+	atomic.StoreUint64(&e.check, hash^data)
+}
@@ -0,0 +1,76 @@
+package pgn
+
+import (
+	"20hh/engine/board"
+)
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// A single move along with everything annotating it
+type Ply struct {
+	Move          board.Move
+	NAGs          []int
+	CommentBefore string
+	Comment       string
+	// Lines played instead of this move
+	Variations [][]Ply
+}
+
+type Game struct {
+	Tags   []Tag // In the order they were read
+	Moves  []Ply // Main line
+	Result string
+}
+
+// Results as they're written in movetext
+const (
+	WhiteWins = "1-0"
+	BlackWins = "0-1"
+	Draw      = "1/2-1/2"
+	NoResult  = "*"
+)
+
+// Returns an empty string if the tag isn't there
+func (game *Game) Tag(name string) string {
+	for _, tag := range game.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// Overwrites the tag if it's already there, adds it otherwise
+func (game *Game) SetTag(name, value string) {
+	for i := range game.Tags {
+		if game.Tags[i].Name == name {
+			game.Tags[i].Value = value
+			return
+		}
+	}
+	game.Tags = append(game.Tags, Tag{name, value})
+}
+
+// The position the game started from, using the FEN tag if there is one
+func (game *Game) StartPosition() (board.Board, error) {
+	board.Init()
+	if fen := game.Tag("FEN"); fen != "" {
+		return board.ParseFEN(fen)
+	}
+	return board.StartPos(), nil
+}
+
+// The position after the last move of the main line
+func (game *Game) FinalPosition() (board.Board, error) {
+	b, err := game.StartPosition()
+	if err != nil {
+		return b, err
+	}
+	for _, ply := range game.Moves {
+		b.MakeMove(ply.Move)
+	}
+	return b, nil
+}
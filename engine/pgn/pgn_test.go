@@ -0,0 +1,217 @@
+package pgn
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const testPGN = `[Event "Paris"]
+[Site "Paris FRA"]
+[Date "1858.??.??"]
+[Round "?"]
+[White "Paul Morphy"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1.e4 e5 2.Nf3 d6 3.d4 Bg4 {This is a weak move already.--Fischer} 4.dxe5
+Bxf3 5.Qxf3 dxe5 6.Bc4 Nf6 7.Qb3 Qe7 8.Nc3 c6 9.Bg5 {Black is in what's
+like a zugzwang position here.} b5 10.Nxb5 cxb5 11.Bxb5+ Nbd7 12.O-O-O Rd8
+13.Rxd7 Rxd7 14.Rd1 Qe6 15.Bxd7+ Nxd7 16.Qb8+ Nxb8 17.Rd8# 1-0
+
+% An escaped line that should be skipped
+[Event "Annotated"]
+[Result "*"]
+
+; A rest-of-line comment
+1. e4! $14 (1. d4 d5 (1... Nf6 2. c4) 2. c4 {Queen's Gambit}) 1... c5!? 2. Nf3
+(2. c3) * 
+
+[Event "From a position"]
+[SetUp "1"]
+[FEN "7k/4P3/8/8/8/8/8/K7 w - - 0 60"]
+
+60. e8=Q# 1-0
+`
+
+func readAll(t *testing.T, text string) []*Game {
+	reader := NewReader(strings.NewReader(text))
+	var games []*Game
+	for {
+		game, err := reader.Next()
+		if err == io.EOF {
+			return games
+		}
+		if err != nil {
+			t.Fatalf("Failed to read game %d: %s", len(games)+1, err)
+		}
+		games = append(games, game)
+	}
+}
+
+func TestReadGames(t *testing.T) {
+	games := readAll(t, testPGN)
+	if len(games) != 3 {
+		t.Fatalf("Read %d games instead of 3", len(games))
+	}
+
+	opera := games[0]
+	if opera.Tag("White") != "Paul Morphy" {
+		t.Errorf("White tag is \"%s\"", opera.Tag("White"))
+	}
+	if len(opera.Moves) != 33 || opera.Result != WhiteWins {
+		t.Errorf("Read %d moves with result %s", len(opera.Moves), opera.Result)
+	}
+	if comment := opera.Moves[5].Comment; comment != "This is a weak move already.--Fischer" {
+		t.Errorf("Comment after 3...Bg4 is \"%s\"", comment)
+	}
+	final, _ := opera.FinalPosition()
+	if fen := final.FEN(); fen != "1n1Rkb1r/p4ppp/4q3/4p1B1/4P3/8/PPP2PPP/2K5 b k - 1 17" {
+		t.Errorf("Final position is %s", fen)
+	}
+
+	annotated := games[1]
+	if len(annotated.Moves) != 3 || annotated.Result != NoResult {
+		t.Fatalf("Read %d moves with result %s", len(annotated.Moves), annotated.Result)
+	}
+	e4 := annotated.Moves[0]
+	if len(e4.NAGs) != 2 || e4.NAGs[0] != 1 || e4.NAGs[1] != 14 {
+		t.Errorf("1. e4 has NAGs %v", e4.NAGs)
+	}
+	if e4.CommentBefore != "A rest-of-line comment" {
+		t.Errorf("Comment before 1. e4 is \"%s\"", e4.CommentBefore)
+	}
+	if len(e4.Variations) != 1 || len(e4.Variations[0]) != 3 {
+		t.Fatalf("1. e4 has variations %v", e4.Variations)
+	}
+	d5 := e4.Variations[0][1]
+	if len(d5.Variations) != 1 || len(d5.Variations[0]) != 2 {
+		t.Errorf("1... d5 has variations %v", d5.Variations)
+	}
+	if comment := e4.Variations[0][2].Comment; comment != "Queen's Gambit" {
+		t.Errorf("Comment after 2. c4 is \"%s\"", comment)
+	}
+	if nags := annotated.Moves[1].NAGs; len(nags) != 1 || nags[0] != 5 {
+		t.Errorf("1... c5 has NAGs %v", nags)
+	}
+
+	fromPosition := games[2]
+	if len(fromPosition.Moves) != 1 || fromPosition.Result != WhiteWins {
+		t.Errorf("Read %d moves with result %s",
+			len(fromPosition.Moves), fromPosition.Result)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		pgn  string
+	}{
+		{"illegal move", "1. e4 e4 *"},
+		{"unclosed variation", "1. e4 (1. d4 *"},
+		{"unmatched parenthesis", "1. e4 ) *"},
+		{"unclosed comment", "1. e4 {never ends"},
+		{"bad tag", "[Event Paris]\n\n1. e4 *"},
+		{"bad FEN tag", "[FEN \"not a fen\"]\n\n1. e4 *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.pgn)).Next()
+			if err == nil || err == io.EOF {
+				t.Errorf("Read without an error")
+			}
+		})
+	}
+}
+
+// A bad game shouldn't stop the rest of the file from being read
+func TestReadAfterError(t *testing.T) {
+	reader := NewReader(strings.NewReader("1. e4 Ke5 2. d4 *\n\n1. d4 d5 1/2-1/2\n"))
+	if _, err := reader.Next(); err == nil {
+		t.Fatalf("Read the bad game without an error")
+	}
+	game, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Failed to read the second game: %s", err)
+	}
+	if len(game.Moves) != 2 || game.Result != Draw {
+		t.Errorf("Read %d moves with result %s", len(game.Moves), game.Result)
+	}
+
+	// A bad tag takes the game's other tags and moves with it
+	reader = NewReader(strings.NewReader(
+		"[Event Paris]\n[Site \"x\"]\n\n1. e4 *\n\n[Event \"B\"]\n\n1. d4 *"))
+	if _, err := reader.Next(); err == nil {
+		t.Fatalf("Read the bad tag without an error")
+	}
+	game, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Failed to read the game after the bad tag: %s", err)
+	}
+	if game.Tag("Event") != "B" || len(game.Moves) != 1 {
+		t.Errorf("Read game %v with %d moves instead of game B", game.Tags, len(game.Moves))
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Read another game after game B (%v)", err)
+	}
+}
+
+func TestWriteGame(t *testing.T) {
+	game := readAll(t, "[White \"Someone\"]\n[ECO \"C20\"]\n\n"+
+		"1. e4 {Best by test} e5 $1 (1... c5 2. Nf3) 2. Nf3 Nc6 0-1")[0]
+
+	var sb strings.Builder
+	if err := WriteGame(&sb, game); err != nil {
+		t.Fatalf("Failed to write: %s", err)
+	}
+	expected := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Someone"]
+[Black "?"]
+[Result "0-1"]
+[ECO "C20"]
+
+1. e4 {Best by test} 1... e5 $1 (1... c5 2. Nf3) 2. Nf3 Nc6 0-1
+
+`
+	if sb.String() != expected {
+		t.Errorf("Wrote:\n%s\ninstead of:\n%s", sb.String(), expected)
+	}
+}
+
+// Reading back what was written should give the same games
+func TestRoundTrip(t *testing.T) {
+	games := readAll(t, testPGN)
+	var sb strings.Builder
+	for _, game := range games {
+		if err := WriteGame(&sb, game); err != nil {
+			t.Fatalf("Failed to write: %s", err)
+		}
+	}
+
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if len(line) > lineLength {
+			t.Errorf("Line is over %d characters: %s", lineLength, line)
+		}
+	}
+
+	reread := readAll(t, sb.String())
+	if len(reread) != len(games) {
+		t.Fatalf("Read back %d games instead of %d", len(reread), len(games))
+	}
+	for i := range games {
+		var first, second strings.Builder
+		WriteGame(&first, games[i])
+		WriteGame(&second, reread[i])
+		if first.String() != second.String() {
+			t.Errorf("Game %d changed after a round trip:\n%s\n%s",
+				i+1, first.String(), second.String())
+		}
+		if len(games[i].Moves) != len(reread[i].Moves) {
+			t.Errorf("Game %d has %d moves after a round trip instead of %d",
+				i+1, len(reread[i].Moves), len(games[i].Moves))
+		}
+	}
+}
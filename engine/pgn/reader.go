@@ -0,0 +1,366 @@
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"20hh/engine/board"
+)
+
+type tokenKind uint8
+
+const (
+	tagToken tokenKind = iota
+	commentToken
+	variationStart
+	variationEnd
+	nagToken
+	symbolToken // Moves, move numbers and results
+	eofToken
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // Tag name, comment text, or symbol
+	value string // Tag value
+	nag   int
+}
+
+// Streams games out of a PGN file one at a time
+type Reader struct {
+	in       *bufio.Reader
+	pushback *token
+	// True at the start of a line, where % escapes a whole line
+	lineStart bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{in: bufio.NewReader(r), lineStart: true}
+}
+
+// Traditional suffix annotations and the NAGs they stand for
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// Reads the next game, returning io.EOF once there are none left
+// If a game can't be read, the error is returned and the rest of
+// it is skipped so the next call can carry on with the following game
+func (reader *Reader) Next() (*Game, error) {
+	game := &Game{}
+
+	// Tag pairs
+	tok, err := reader.nextToken()
+	for err == nil && tok.kind == tagToken {
+		game.Tags = append(game.Tags, Tag{tok.text, tok.value})
+		tok, err = reader.nextToken()
+	}
+	if err != nil {
+		reader.skipGame(true)
+		return nil, err
+	}
+	if tok.kind == eofToken {
+		if len(game.Tags) == 0 {
+			return nil, io.EOF
+		}
+	}
+	reader.pushback = &tok
+
+	// Movetext
+	b, err := game.StartPosition()
+	if err != nil {
+		reader.skipGame(false)
+		return nil, err
+	}
+	game.Moves, err = reader.readLine(&b, game, 0)
+	if err != nil {
+		reader.skipGame(false)
+		return nil, err
+	}
+	if game.Result == "" {
+		game.Result = game.Tag("Result")
+	}
+	if game.Result == "" {
+		game.Result = NoResult
+	}
+	return game, nil
+}
+
+// Reads moves until the end of the game or variation
+// Variations are played out on the board and then taken back
+func (reader *Reader) readLine(b *board.Board, game *Game, depth int) ([]Ply, error) {
+	var plies []Ply
+	comment := ""
+	for {
+		tok, err := reader.nextToken()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok.kind {
+		case eofToken:
+			if depth > 0 {
+				return nil, fmt.Errorf("pgn: unterminated variation")
+			}
+			return plies, nil
+
+		case tagToken:
+			if depth > 0 {
+				return nil, fmt.Errorf("pgn: tag [%s] inside a variation", tok.text)
+			}
+			// The game was missing its result, so this starts the next one
+			reader.pushback = &tok
+			return plies, nil
+
+		case commentToken:
+			if len(plies) == 0 || comment != "" {
+				comment = joinComments(comment, tok.text)
+			} else {
+				last := &plies[len(plies)-1]
+				last.Comment = joinComments(last.Comment, tok.text)
+			}
+
+		case nagToken:
+			if len(plies) == 0 {
+				return nil, fmt.Errorf("pgn: $%d before any move", tok.nag)
+			}
+			last := &plies[len(plies)-1]
+			last.NAGs = append(last.NAGs, tok.nag)
+
+		case variationStart:
+			if len(plies) == 0 {
+				return nil, fmt.Errorf("pgn: variation before any move")
+			}
+			last := &plies[len(plies)-1]
+			b.UndoMove(last.Move)
+			variation, err := reader.readLine(b, game, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			b.MakeMove(last.Move)
+			last.Variations = append(last.Variations, variation)
+
+		case variationEnd:
+			if depth == 0 {
+				return nil, fmt.Errorf("pgn: unexpected ')'")
+			}
+			for i := len(plies) - 1; i >= 0; i-- {
+				b.UndoMove(plies[i].Move)
+			}
+			return plies, nil
+
+		case symbolToken:
+			switch tok.text {
+			case WhiteWins, BlackWins, Draw, NoResult:
+				if depth > 0 {
+					return nil, fmt.Errorf("pgn: result %s inside a variation", tok.text)
+				}
+				game.Result = tok.text
+				return plies, nil
+			}
+
+			san := trimMoveNumber(tok.text)
+			if san == "" {
+				continue
+			}
+			san, nag := splitSuffixAnnotation(san)
+			move, err := b.ParseSAN(san)
+			if err != nil {
+				return nil, fmt.Errorf("pgn: %w", err)
+			}
+			ply := Ply{Move: move, CommentBefore: comment}
+			if nag > 0 {
+				ply.NAGs = append(ply.NAGs, nag)
+			}
+			comment = ""
+			plies = append(plies, ply)
+			b.MakeMove(move)
+		}
+	}
+}
+
+// Throws away tokens up to the end of the current game
+// A game that went wrong in its tags still has the rest of them to skip
+// before the movetext, otherwise a tag marks the start of the next game
+func (reader *Reader) skipGame(inTags bool) {
+	for {
+		tok, err := reader.nextToken()
+		if err != nil || tok.kind == eofToken {
+			return
+		}
+		if tok.kind == tagToken {
+			if inTags {
+				continue
+			}
+			reader.pushback = &tok
+			return
+		}
+		inTags = false
+		if tok.kind == symbolToken {
+			switch tok.text {
+			case WhiteWins, BlackWins, Draw, NoResult:
+				return
+			}
+		}
+	}
+}
+
+func joinComments(first, second string) string {
+	if first == "" {
+		return second
+	}
+	return first + " " + second
+}
+
+// Strips move numbers like "12." or "12..." off the front of a symbol
+func trimMoveNumber(symbol string) string {
+	digits := 0
+	for digits < len(symbol) && symbol[digits] >= '0' && symbol[digits] <= '9' {
+		digits++
+	}
+	dots := digits
+	for dots < len(symbol) && symbol[dots] == '.' {
+		dots++
+	}
+	if digits == 0 || dots == digits {
+		// Not a move number, but a lone "..." can show up too
+		return strings.TrimLeft(symbol, ".")
+	}
+	return symbol[dots:]
+}
+
+func splitSuffixAnnotation(san string) (string, int) {
+	end := len(san)
+	for end > 0 && (san[end-1] == '!' || san[end-1] == '?') {
+		end--
+	}
+	return san[:end], suffixNAGs[san[end:]]
+}
+
+func (reader *Reader) nextToken() (token, error) {
+	if reader.pushback != nil {
+		tok := *reader.pushback
+		reader.pushback = nil
+		return tok, nil
+	}
+
+	for {
+		char, _, err := reader.in.ReadRune()
+		if err == io.EOF {
+			return token{kind: eofToken}, nil
+		}
+		if err != nil {
+			return token{}, err
+		}
+		lineStart := reader.lineStart
+		reader.lineStart = char == '\n'
+
+		switch {
+		case char == '%' && lineStart:
+			// Escaped line, ignored completely
+			reader.readUntil('\n')
+			reader.lineStart = true
+		case char == ' ' || char == '\t' || char == '\r' || char == '\n':
+			continue
+		case char == '[':
+			return reader.readTag()
+		case char == '{':
+			text, err := reader.readUntil('}')
+			return token{kind: commentToken, text: strings.Join(strings.Fields(text), " ")}, err
+		case char == ';':
+			text, err := reader.readUntil('\n')
+			reader.lineStart = true
+			return token{kind: commentToken, text: strings.TrimSpace(text)}, err
+		case char == '(':
+			return token{kind: variationStart}, nil
+		case char == ')':
+			return token{kind: variationEnd}, nil
+		case char == '$':
+			digits := reader.readSymbol()
+			nag, err := strconv.Atoi(digits)
+			if err != nil {
+				return token{}, fmt.Errorf("pgn: bad NAG $%s", digits)
+			}
+			return token{kind: nagToken, nag: nag}, nil
+		default:
+			reader.in.UnreadRune()
+			return token{kind: symbolToken, text: reader.readSymbol()}, nil
+		}
+	}
+}
+
+// Reads up to and including the delimiter, returning what came before it
+// Running out of input first is only an error if something was read
+func (reader *Reader) readUntil(delim byte) (string, error) {
+	text, err := reader.in.ReadString(delim)
+	if err == io.EOF {
+		if delim == '\n' {
+			return text, nil
+		}
+		return text, fmt.Errorf("pgn: missing '%c'", delim)
+	}
+	return strings.TrimSuffix(text, string(delim)), err
+}
+
+func (reader *Reader) readSymbol() string {
+	var sb strings.Builder
+	for {
+		char, _, err := reader.in.ReadRune()
+		if err != nil {
+			return sb.String()
+		}
+		if strings.ContainsRune(" \t\r\n{}()[];$", char) {
+			reader.in.UnreadRune()
+			return sb.String()
+		}
+		sb.WriteRune(char)
+	}
+}
+
+// Reads a [Name "Value"] tag pair, with the opening bracket already read
+func (reader *Reader) readTag() (token, error) {
+	text, err := reader.readUntilTagEnd()
+	if err != nil {
+		return token{}, err
+	}
+	name, rest, _ := strings.Cut(strings.TrimSpace(text), " ")
+	rest = strings.TrimSpace(rest)
+	if name == "" || len(rest) < 2 || rest[0] != '"' || rest[len(rest)-1] != '"' {
+		return token{}, fmt.Errorf("pgn: bad tag [%s]", text)
+	}
+	value := rest[1 : len(rest)-1]
+	value = strings.ReplaceAll(value, `\"`, `"`)
+	value = strings.ReplaceAll(value, `\\`, `\`)
+	return token{kind: tagToken, text: name, value: value}, nil
+}
+
+// Finds the closing bracket, skipping over any inside the quoted value
+func (reader *Reader) readUntilTagEnd() (string, error) {
+	var sb strings.Builder
+	inQuotes, escaped := false, false
+	for {
+		char, _, err := reader.in.ReadRune()
+		if err != nil {
+			return "", fmt.Errorf("pgn: unterminated tag [%s", sb.String())
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\' && inQuotes:
+			escaped = true
+		case char == '"':
+			inQuotes = !inQuotes
+		case char == ']' && !inQuotes:
+			return sb.String(), nil
+		}
+		sb.WriteRune(char)
+	}
+}
@@ -0,0 +1,151 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"20hh/engine/board"
+)
+
+// Movetext lines are kept under this many characters
+const lineLength = 80
+
+// The Seven Tag Roster, which always comes first and in this order
+var rosterTags = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+var rosterDefaults = map[string]string{
+	"Event": "?",
+	"Site":  "?",
+	"Date":  "????.??.??",
+	"Round": "?",
+	"White": "?",
+	"Black": "?",
+}
+
+// Writes a game out in export format
+func WriteGame(w io.Writer, game *Game) error {
+	result := game.Result
+	if result == "" {
+		result = NoResult
+	}
+
+	var sb strings.Builder
+	for _, name := range rosterTags {
+		value := game.Tag(name)
+		if name == "Result" {
+			value = result
+		} else if value == "" {
+			value = rosterDefaults[name]
+		}
+		writeTag(&sb, name, value)
+	}
+	for _, tag := range game.Tags {
+		if !isRosterTag(tag.Name) {
+			writeTag(&sb, tag.Name, tag.Value)
+		}
+	}
+	sb.WriteByte('\n')
+
+	b, err := game.StartPosition()
+	if err != nil {
+		return err
+	}
+	var tokens []string
+	tokens = appendLine(tokens, &b, game.Moves)
+	tokens = append(tokens, result)
+	writeWrapped(&sb, tokens)
+	sb.WriteByte('\n')
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func isRosterTag(name string) bool {
+	for _, rosterName := range rosterTags {
+		if name == rosterName {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, value)
+}
+
+// Turns a line of moves into movetext tokens, leaving the board as it was
+func appendLine(tokens []string, b *board.Board, plies []Ply) []string {
+	// The move number has to be repeated for black after anything
+	// that interrupts the moves
+	needsNumber := true
+	for _, ply := range plies {
+		if ply.CommentBefore != "" {
+			tokens = append(tokens, commentTokens(ply.CommentBefore)...)
+			needsNumber = true
+		}
+
+		moveNumber := b.TotalHalfMoves()/2 + 1
+		if b.WhiteToMove() {
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		} else if needsNumber {
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+		needsNumber = false
+
+		tokens = append(tokens, b.MoveToSAN(ply.Move))
+		for _, nag := range ply.NAGs {
+			tokens = append(tokens, fmt.Sprintf("$%d", nag))
+		}
+		if ply.Comment != "" {
+			tokens = append(tokens, commentTokens(ply.Comment)...)
+			needsNumber = true
+		}
+
+		for _, variation := range ply.Variations {
+			tokens = append(tokens, "(")
+			tokens = appendLine(tokens, b, variation)
+			tokens = append(tokens, ")")
+			needsNumber = true
+		}
+		b.MakeMove(ply.Move)
+	}
+
+	for i := len(plies) - 1; i >= 0; i-- {
+		b.UndoMove(plies[i].Move)
+	}
+	return tokens
+}
+
+// Split into words so long comments can wrap
+func commentTokens(comment string) []string {
+	words := strings.Fields(comment)
+	if len(words) == 0 {
+		return []string{"{}"}
+	}
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+	return words
+}
+
+func writeWrapped(sb *strings.Builder, tokens []string) {
+	lineLen := 0
+	for i, tok := range tokens {
+		// No space just inside variation parentheses
+		spaced := i > 0 && tokens[i-1] != "(" && tok != ")"
+		if spaced && lineLen+1+len(tok) > lineLength {
+			sb.WriteByte('\n')
+			lineLen = 0
+			spaced = false
+		}
+		if spaced {
+			sb.WriteByte(' ')
+			lineLen++
+		}
+		sb.WriteString(tok)
+		lineLen += len(tok)
+	}
+	sb.WriteByte('\n')
+}
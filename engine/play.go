@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"20hh/engine/board"
+	"20hh/engine/search"
+)
+
+// How long the engine gets for each move in a game against a human
+const playMoveTimeMs = 3000
+
+// Plays a game against the engine in the terminal, with the board drawn
+// from the human's side and moves entered in SAN or UCI notation
+func PlayLoop(in io.Reader, humanColor int, color bool) {
+	var engine Engine
+	engine.GameFromStartPos()
+	engine.ResetSearch()
+
+	opts := board.RenderOptions{
+		Unicode:   true,
+		Color:     color,
+		FromBlack: humanColor == board.Black,
+	}
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Println(engine.RenderBoard(opts))
+		fmt.Println()
+
+		b := &engine.currentBoard
+		if status := b.Status(); status != board.Ongoing {
+			fmt.Printf("Game over: %s\n", status)
+			return
+		}
+
+		whoseTurn := board.White
+		if b.BlackToMove() {
+			whoseTurn = board.Black
+		}
+		if whoseTurn != humanColor {
+			move := engine.GetBestMove(
+				SearchOpts{timeRemaining: playMoveTimeMs * 40, maxNodes: int((^uint(0)) >> 1)},
+				func(search.SearchLog) {},
+			)
+			fmt.Printf("20HH plays %s\n", b.MoveToSAN(move))
+			engine.PlayMoveFromUCI(engine.MoveToUCI(move))
+			continue
+		}
+
+		for {
+			fmt.Print("Your move: ")
+			line, err := reader.ReadString('\n')
+			line = strings.TrimSpace(line)
+			if line == "quit" || (err != nil && line == "") {
+				return
+			}
+			if move, ok := parseHumanMove(b, line); ok {
+				engine.PlayMoveFromUCI(engine.MoveToUCI(move))
+				break
+			}
+			fmt.Printf("\"%s\" isn't a legal move\n", line)
+		}
+	}
+}
+
+// Accepts either SAN like Nf3 or UCI like g1f3
+func parseHumanMove(b *board.Board, text string) (board.Move, bool) {
+	if move, err := b.ParseSAN(text); err == nil {
+		return move, true
+	}
+	var legalMoves board.MoveList
+	b.GenLegalMoves(&legalMoves, false)
+	for _, move := range legalMoves.Slice() {
+		if b.MoveToUCI(move) == text {
+			return move, true
+		}
+	}
+	return board.NullMove, false
+}
@@ -93,11 +93,11 @@ var KING_ENDGAME = [64]int{
 }
 
 func evalPosition(b *board.Board) int16 {
+	if b.Variant() == board.Antichess {
+		return antichessEval(b)
+	}
 	whiteBB, blackBB := b.ColorBitboards()
 	pieceArray := b.PieceArray()
-	if b.HalfMoveClock() >= 100 {
-		return 0
-	}
 	whiteScore := int16(0)
 	for whiteBB > 0 {
 		idx := whiteBB.PopLSB()
@@ -115,6 +115,9 @@ func evalPosition(b *board.Board) int16 {
 		blackScore += PIECE_TABLES[piece][idx]
 	}
 
+	whiteScore += variantBonus(b, board.White)
+	blackScore += variantBonus(b, board.Black)
+
 	if b.BlackToMove() {
 		return blackScore - whiteScore
 	} else {
@@ -122,15 +125,66 @@ func evalPosition(b *board.Board) int16 {
 	}
 }
 
-// Determine if this position has been played already
-func IsRepetition(b *board.Board) bool {
-	start := b.TotalHalfMoves() - b.HalfMoveClock()
-	hash := b.Hash()
-	for i := start; i < b.TotalHalfMoves(); i++ {
-		if b.PosAtNthPly(i) == hash {
-			return true
+// Each check given in Three-check is worth more than the last
+var CHECK_BONUSES = [...]int16{0, 150, 400, 1000}
+
+// How close a king is to the center in King of the Hill,
+// by how many king moves it is from the nearest center square
+var HILL_BONUSES = [...]int16{1000, 150, 50, 0}
+
+// Scores for what only a variant's rules care about, like how close
+// a color is to winning by them or what it has in hand
+func variantBonus(b *board.Board, color int) int16 {
+	switch b.Variant() {
+	case board.ThreeCheck:
+		return CHECK_BONUSES[b.ChecksGiven(color)]
+	case board.KingOfTheHill:
+		kingSq, ok := b.KingSquare(color)
+		if !ok {
+			return 0
 		}
+		rank, file := int(kingSq/8), int(kingSq%8)
+		distance := max(3-rank, rank-4, 3-file, file-4, 0)
+		return HILL_BONUSES[distance]
+	case board.Crazyhouse:
+		// Pieces in hand are worth about as much as they are on the board
+		bonus := int16(0)
+		for piece := board.Pawn; piece < board.King; piece++ {
+			bonus += int16(b.InPocket(color, piece)) * PIECE_VALUES[piece]
+		}
+		return bonus
+	}
+	return 0
+}
+
+// What each piece costs its owner in Antichess, where the goal is to get rid
+// of them. Long range pieces are the hardest to keep from being forced into
+// captures, and the king is an ordinary piece
+var ANTICHESS_VALUES = [...]int16{
+	0,
+	100,
+	200,
+	250,
+	350,
+	400,
+	250,
+}
+
+// Antichess turns the usual goal upside down, so less material is better
+// and none of the piece tables apply
+func antichessEval(b *board.Board) int16 {
+	whiteBB, blackBB := b.ColorBitboards()
+	pieceArray := b.PieceArray()
+	score := int16(0)
+	for whiteBB > 0 {
+		score -= ANTICHESS_VALUES[pieceArray[whiteBB.PopLSB()]]
+	}
+	for blackBB > 0 {
+		score += ANTICHESS_VALUES[pieceArray[blackBB.PopLSB()]]
 	}
 
-	return false
+	if b.BlackToMove() {
+		return -score
+	}
+	return score
 }
@@ -0,0 +1,173 @@
+package search
+
+import (
+	"20hh/engine/board"
+)
+
+type pickerStage uint8
+
+const (
+	ttMoveStage = pickerStage(iota)
+	genCapturesStage
+	capturesStage
+	killersStage
+	genQuietsStage
+	quietsStage
+	badCapturesStage
+	doneStage
+)
+
+// Hands out moves one at a time in the order they're likely to be best:
+// the TT move, then captures (most valuable victim, least valuable attacker),
+// then killer moves, then everything else, and finally captures that
+// lose material according to SEE
+// Each stage is only generated once the one before it runs out,
+// so a beta cutoff early on skips the rest of the work
+// With capturesOnly, losing captures are left out altogether
+// Antichess captures aren't optional, so they're never held back
+// The moves go in a list the caller owns, which the picker clears first
+type MovePicker struct {
+	b            *board.Board
+	stage        pickerStage
+	capturesOnly bool
+	useSEE       bool
+
+	ttMove    board.Move
+	killers   [2]board.Move
+	killerIdx int
+
+	moves *board.MoveList
+	idx   int
+	// Losing captures are moved to the front of the list,
+	// into the slots of captures that were already handed out
+	badCaptures int
+}
+
+func NewMovePicker(b *board.Board, moves *board.MoveList, ttMove board.Move,
+	killers [2]board.Move, capturesOnly bool) MovePicker {
+	return MovePicker{
+		b:            b,
+		moves:        moves,
+		ttMove:       ttMove,
+		killers:      killers,
+		capturesOnly: capturesOnly,
+		useSEE:       b.Variant() != board.Antichess,
+	}
+}
+
+// Returns the next pseudo-legal move, or NullMove once there are none left
+func (mp *MovePicker) Next() board.Move {
+	for {
+		switch mp.stage {
+		case ttMoveStage:
+			mp.stage = genCapturesStage
+			if mp.ttMove != board.NullMove && !mp.capturesOnly &&
+				mp.b.IsLegal(mp.ttMove) {
+				return mp.ttMove
+			}
+			mp.ttMove = board.NullMove
+
+		case genCapturesStage:
+			mp.moves.Clear()
+			mp.b.GenMoves(mp.moves, true)
+			mp.scoreCaptures()
+			mp.stage = capturesStage
+
+		case capturesStage:
+			move := mp.pickBest()
+			if move == board.NullMove {
+				mp.stage = killersStage
+				if mp.capturesOnly {
+					mp.stage = doneStage
+				}
+				continue
+			}
+			if mp.useSEE && !mp.b.SEEGreaterOrEqual(move, 0) {
+				mp.moves.Moves[mp.badCaptures] = move
+				mp.badCaptures++
+				continue
+			}
+			return move
+
+		case killersStage:
+			for mp.killerIdx < len(mp.killers) {
+				killer := mp.killers[mp.killerIdx]
+				mp.killerIdx++
+				// Captures were already handed out
+				if killer != board.NullMove && killer != mp.ttMove &&
+					!killer.HasFlag(board.Capture) && mp.b.IsLegal(killer) {
+					return killer
+				}
+			}
+			mp.stage = genQuietsStage
+
+		case genQuietsStage:
+			// Quiets go after the captures, leaving the losing ones alone
+			mp.idx = mp.moves.Count
+			mp.b.GenQuietMoves(mp.moves)
+			mp.stage = quietsStage
+
+		case quietsStage:
+			for mp.idx < mp.moves.Count {
+				move := mp.moves.Moves[mp.idx]
+				mp.idx++
+				if move != mp.ttMove && !mp.isKiller(move) {
+					return move
+				}
+			}
+			mp.idx = 0
+			mp.stage = badCapturesStage
+
+		case badCapturesStage:
+			if mp.idx < mp.badCaptures {
+				mp.idx++
+				return mp.moves.Moves[mp.idx-1]
+			}
+			mp.stage = doneStage
+
+		case doneStage:
+			return board.NullMove
+		}
+	}
+}
+
+func (mp *MovePicker) scoreCaptures() {
+	pieces := mp.b.PieceArray()
+	for i, move := range mp.moves.Slice() {
+		victim := pieces[move.GetTo()]
+		if move.GetFlag() == board.EnPassant {
+			victim = board.Pawn
+		}
+		attacker := pieces[move.GetFrom()]
+		mp.moves.Scores[i] = int32(PIECE_VALUES[victim])*10 - int32(attacker)
+		if move.HasFlag(board.Promotion) {
+			mp.moves.Scores[i] += int32(PIECE_VALUES[board.Queen])
+		}
+	}
+	mp.idx = 0
+}
+
+// Selection sort one move at a time, since a cutoff
+// usually comes before all of them are needed
+func (mp *MovePicker) pickBest() board.Move {
+	ml := mp.moves
+	for mp.idx < ml.Count {
+		best := mp.idx
+		for i := mp.idx + 1; i < ml.Count; i++ {
+			if ml.Scores[i] > ml.Scores[best] {
+				best = i
+			}
+		}
+		ml.Swap(mp.idx, best)
+		move := ml.Moves[mp.idx]
+		mp.idx++
+		if move != mp.ttMove {
+			return move
+		}
+	}
+	return board.NullMove
+}
+
+func (mp *MovePicker) isKiller(move board.Move) bool {
+	return move == mp.killers[0] || move == mp.killers[1]
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"testing"
+
+	"20hh/engine/board"
+)
+
+var pickerFENs = []string{
+	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+}
+
+func pickAll(b *board.Board, ttMove board.Move, killers [2]board.Move, capturesOnly bool) []board.Move {
+	var moveList board.MoveList
+	picker := NewMovePicker(b, &moveList, ttMove, killers, capturesOnly)
+	var moves []board.Move
+	for move := picker.Next(); move != board.NullMove; move = picker.Next() {
+		moves = append(moves, move)
+	}
+	return moves
+}
+
+// The picker should give every generated move exactly once,
+// whatever TT move and killers it's handed
+func TestMovePickerCoversMoves(t *testing.T) {
+	board.Init()
+	for _, fen := range pickerFENs {
+		b := board.FromFEN(fen)
+		var generated, legalMoves board.MoveList
+		b.GenMoves(&generated, false)
+		expected := make(map[board.Move]bool)
+		for _, move := range generated.Slice() {
+			expected[move] = true
+		}
+		b.GenLegalMoves(&legalMoves, false)
+		legal := legalMoves.Slice()
+
+		// Try every legal move as the TT move, paired with a couple of killers
+		for i, ttMove := range legal {
+			killers := [2]board.Move{legal[(i+1)%len(legal)], legal[(i+5)%len(legal)]}
+			picked := pickAll(&b, ttMove, killers, false)
+			if picked[0] != ttMove {
+				t.Errorf("%s: picked %s first instead of TT move %s", fen, picked[0], ttMove)
+			}
+
+			seen := make(map[board.Move]bool)
+			for _, move := range picked {
+				if seen[move] {
+					t.Errorf("%s: %s picked twice", fen, move)
+				}
+				seen[move] = true
+				if !expected[move] {
+					t.Errorf("%s: %s picked but never generated", fen, move)
+				}
+			}
+			if len(seen) != len(expected) {
+				t.Errorf("%s: picked %d moves instead of %d", fen, len(seen), len(expected))
+			}
+		}
+
+		// A TT move that isn't legal here gets ignored
+		bogus := board.NewMove(board.A1, board.H8, board.NoFlag)
+		if picked := pickAll(&b, bogus, [2]board.Move{}, false); len(picked) != generated.Count {
+			t.Errorf("%s: illegal TT move changed the move count to %d", fen, len(picked))
+		}
+	}
+}
+
+func TestMovePickerCaptureOrder(t *testing.T) {
+	board.Init()
+	for _, fen := range pickerFENs {
+		b := board.FromFEN(fen)
+		picked := pickAll(&b, board.NullMove, [2]board.Move{}, true)
+		var captures board.MoveList
+		b.GenMoves(&captures, true)
+		goodCaptures := 0
+		for _, move := range captures.Slice() {
+			if b.SEE(move) >= 0 {
+				goodCaptures++
+			}
+		}
+		if len(picked) != goodCaptures {
+			t.Errorf("%s: picked %d captures instead of %d", fen, len(picked), goodCaptures)
+		}
+
+		pieces := b.PieceArray()
+		lastVictim := board.King + 1
+		for _, move := range picked {
+			if !move.HasFlag(board.Capture) {
+				t.Errorf("%s: %s isn't a capture", fen, move)
+			}
+			victim := pieces[move.GetTo()]
+			if move.GetFlag() == board.EnPassant {
+				victim = board.Pawn
+			}
+			if !move.HasFlag(board.Promotion) && victim > lastVictim {
+				t.Errorf("%s: %s came after a less valuable victim", fen, move)
+			}
+			if !move.HasFlag(board.Promotion) {
+				lastVictim = victim
+			}
+		}
+	}
+}
+
+// Captures that lose material go after the quiet moves
+func TestMovePickerBadCapturesLast(t *testing.T) {
+	board.Init()
+	for _, fen := range pickerFENs {
+		b := board.FromFEN(fen)
+		picked := pickAll(&b, board.NullMove, [2]board.Move{}, false)
+		seenBad := false
+		for _, move := range picked {
+			bad := move.HasFlag(board.Capture) && b.SEE(move) < 0
+			if seenBad && !bad {
+				t.Errorf("%s: %s came after a losing capture", fen, move)
+			}
+			seenBad = seenBad || bad
+		}
+	}
+}
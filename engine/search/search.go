@@ -21,10 +21,17 @@ type Searcher struct {
 	searchedOneMove    bool
 
 	tt TranspositionTable
+	// Quiet moves that caused a beta cutoff, by ply
+	killers [256][2]board.Move
+	// Where each ply generates its moves, so nodes don't allocate their own
+	moveLists []board.MoveList
 }
 
 func (s *Searcher) Reset(ttSizeMb uint16) {
 	s.tt = NewTT(ttSizeMb)
+	if s.moveLists == nil {
+		s.moveLists = make([]board.MoveList, 256)
+	}
 }
 
 func (s *Searcher) CancelSearch() {
@@ -51,6 +58,7 @@ func (s *Searcher) StartSearch(b *board.Board, out chan board.Move,
 	s.searchCancelled = false
 	s.totalNodesSearched = 0
 	s.maxNodes = maxNodes
+	s.killers = [256][2]board.Move{}
 	timeSearchingMs := uint64(0)
 
 	eval := NEG_INFINITY
@@ -119,7 +127,12 @@ func (s *Searcher) search(b *board.Board, alpha, beta int16, depth, ply uint8) i
 	if s.searchCancelled {
 		return 0
 	}
-	if IsRepetition(b) {
+	// Winning or losing by a variant's rules is as good as a checkmate
+	if status := b.VariantStatus(); status != board.Ongoing {
+		return terminalScore(status, ply)
+	}
+	// The root still needs a move even if the game is drawn
+	if ply > 0 && b.IsDrawn() {
 		return 0
 	}
 	s.totalNodesSearched++
@@ -137,15 +150,13 @@ func (s *Searcher) search(b *board.Board, alpha, beta int16, depth, ply uint8) i
 		return ttEval
 	}
 
-	allMoves, _ := b.GenMoves(false)
-
-	orderMoves(allMoves, ttMove)
+	picker := NewMovePicker(b, &s.moveLists[ply], ttMove, s.killers[ply], false)
 
 	ttFlag := LowerBound
 
 	bestMove := board.NullMove
 	legalMoves := 0
-	for _, move := range allMoves {
+	for move := picker.Next(); move != board.NullMove; move = picker.Next() {
 		if !b.MakeMove(move) {
 			continue
 		}
@@ -158,6 +169,7 @@ func (s *Searcher) search(b *board.Board, alpha, beta int16, depth, ply uint8) i
 		}
 
 		if score >= beta {
+			s.storeKiller(move, ply)
 			ttFlag = UpperBound
 			s.tt.TryPut(
 				b.Hash(), ply, depth,
@@ -175,11 +187,7 @@ func (s *Searcher) search(b *board.Board, alpha, beta int16, depth, ply uint8) i
 		}
 	}
 	if legalMoves == 0 {
-		if b.InCheck() {
-			return NEG_INFINITY + int16(ply) // CHECKMATE
-		} else {
-			return 0 // STALEMATE
-		}
+		return terminalScore(b.NoMovesStatus(), ply)
 	}
 	if bestMove != board.NullMove {
 		s.tt.TryPut(
@@ -200,18 +208,32 @@ func (s *Searcher) qSearch(b *board.Board, alpha, beta int16, ply uint8) int16 {
 	if s.totalNodesSearched >= s.maxNodes {
 		s.searchCancelled = true
 	}
+	if status := b.VariantStatus(); status != board.Ongoing {
+		return terminalScore(status, ply)
+	}
 
 	// eval anyway in case it's a bad capture
-	eval := evalPosition(b)
-	if eval >= beta {
-		return beta
+	// Captures are compulsory in Antichess though, so standing pat
+	// is only an option when there aren't any
+	standPat := true
+	if b.Variant() == board.Antichess {
+		captures := &s.moveLists[ply]
+		captures.Clear()
+		b.GenMoves(captures, true)
+		standPat = captures.Count == 0
 	}
-	if eval > alpha {
-		alpha = eval
+	if standPat {
+		eval := evalPosition(b)
+		if eval >= beta {
+			return beta
+		}
+		if eval > alpha {
+			alpha = eval
+		}
 	}
 
-	quietMoves, _ := b.GenMoves(true)
-	for _, move := range quietMoves {
+	picker := NewMovePicker(b, &s.moveLists[ply], board.NullMove, [2]board.Move{}, true)
+	for move := picker.Next(); move != board.NullMove; move = picker.Next() {
 		if !b.MakeMove(move) {
 			continue
 		}
@@ -229,3 +251,28 @@ func (s *Searcher) qSearch(b *board.Board, alpha, beta int16, ply uint8) int16 {
 	}
 	return alpha
 }
+
+// The score of a finished game for the side to move, preferring
+// quicker wins and slower losses
+func terminalScore(status board.Status, ply uint8) int16 {
+	switch {
+	case status.IsWin():
+		return INFINITY - int16(ply)
+	case status.IsLoss():
+		return NEG_INFINITY + int16(ply)
+	default:
+		return 0
+	}
+}
+
+// Remembers a quiet move that refuted a position, since it'll
+// likely refute its siblings too
+func (s *Searcher) storeKiller(move board.Move, ply uint8) {
+	if move.HasFlag(board.Capture) || move.HasFlag(board.Promotion) {
+		return
+	}
+	if s.killers[ply][0] != move {
+		s.killers[ply][1] = s.killers[ply][0]
+		s.killers[ply][0] = move
+	}
+}
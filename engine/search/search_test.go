@@ -0,0 +1,132 @@
+package search
+
+import (
+	"testing"
+
+	"20hh/engine/board"
+)
+
+func searchNodes(b *board.Board, maxNodes int) board.Move {
+	var s Searcher
+	s.Reset(1)
+	moveChan := make(chan board.Move)
+	go s.StartSearch(b, moveChan, func(SearchLog) {}, maxNodes)
+	return <-moveChan
+}
+
+// A single fixed depth search from scratch, without iterative deepening
+func searchDepth(b *board.Board, depth uint8) int16 {
+	var s Searcher
+	s.Reset(1)
+	s.maxNodes = int((^uint(0)) >> 1)
+	return s.search(b, NEG_INFINITY, INFINITY, depth, 0)
+}
+
+// A game long enough to overflow the old fixed size history,
+// searched at the end to make sure it still comes back with a move
+func TestSearchLongGame(t *testing.T) {
+	board.Init()
+	b := board.StartPos()
+	shuffle := []string{"b1c3", "b8c6", "c3b1", "c6b8"}
+	for ply := 0; ply < 520; ply++ {
+		b.UCIMakeMove(shuffle[ply%len(shuffle)])
+	}
+	if b.TotalHalfMoves() != 520 {
+		t.Fatalf("Game has %d half moves instead of 520", b.TotalHalfMoves())
+	}
+	fen := b.FEN()
+
+	// The root is a repetition, but there still has to be a move
+	move := searchNodes(&b, 20000)
+	if !b.IsLegal(move) {
+		t.Errorf("Search returned %s, which isn't legal", move)
+	}
+	if after := b.FEN(); after != fen {
+		t.Errorf("Search left the board at %s instead of %s", after, fen)
+	}
+	if b.RepetitionCount() != 131 {
+		t.Errorf("Position counted %d times instead of 131 after search", b.RepetitionCount())
+	}
+}
+
+// Evaluation and search shouldn't care which side they're looking from
+func TestMirroredSymmetry(t *testing.T) {
+	board.Init()
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 b - - 0 10",
+	}
+	for _, fen := range fens {
+		b := board.FromFEN(fen)
+		mirrored := b.Mirror()
+		if eval, mirroredEval := evalPosition(&b), evalPosition(&mirrored); eval != mirroredEval {
+			t.Errorf("%s evaluates to %d but its mirror to %d", fen, eval, mirroredEval)
+		}
+		for depth := uint8(1); depth <= 3; depth++ {
+			score := searchDepth(&b, depth)
+			mirroredScore := searchDepth(&mirrored, depth)
+			if score != mirroredScore {
+				t.Errorf("%s searches to %d at depth %d but its mirror to %d",
+					fen, score, depth, mirroredScore)
+			}
+		}
+	}
+}
+
+// Winning by a variant's rules is scored like checkmate, and
+// variant moves like drops can be found
+func TestVariantWins(t *testing.T) {
+	board.Init()
+	var tests = []struct {
+		name    string
+		variant board.Variant
+		fen     string
+		best    string
+	}{
+		{"Third check", board.ThreeCheck, "4k3/8/8/8/8/8/8/4K2R w - - 1+3 0 1", "h1h8"},
+		{"King to the center", board.KingOfTheHill, "3r3k/8/8/8/8/3K4/8/8 w - - 0 1", "d3e4"},
+		{"Smothered by a drop", board.Crazyhouse, "6rk/6pp/8/8/8/8/8/K7[N] w - - 0 1", "N@f7"},
+		// Ka2 and Kb1 both leave black the last word
+		{"Giving away the king", board.Antichess, "8/8/8/8/8/p7/8/K7 w - - 0 1", "a1b2"},
+	}
+	for _, tt := range tests {
+		b, err := board.ParseVariantFEN(tt.fen, tt.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score := searchDepth(&b, 2); score < CHECKMATE_EVAL {
+			t.Errorf("%s: scored %d instead of a win", tt.name, score)
+		}
+		if move := searchNodes(&b, 20000); move.String() != tt.best {
+			t.Errorf("%s: played %s instead of %s", tt.name, move, tt.best)
+		}
+	}
+}
+
+// A fixed depth search from a quiet and a tactical middlegame
+func BenchmarkSearch(b *testing.B) {
+	board.Init()
+	for _, fen := range []string{
+		board.StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	} {
+		pos := board.FromFEN(fen)
+		b.Run(fen[:8], func(b *testing.B) {
+			b.ReportAllocs()
+			nodes := 0
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				var s Searcher
+				s.Reset(16)
+				s.maxNodes = int((^uint(0)) >> 1)
+				b.StartTimer()
+				s.search(&pos, NEG_INFINITY, INFINITY, 5, 0)
+				nodes += s.totalNodesSearched
+			}
+			b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+		})
+	}
+}
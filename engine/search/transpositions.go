@@ -9,7 +9,7 @@ const (
 	LowerBound uint8 = 0b10
 	UpperBound uint8 = 0b11
 
-	EntrySize = 14
+	EntrySize = 16
 )
 
 type _TableEntry struct {
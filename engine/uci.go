@@ -2,6 +2,7 @@ package engine
 
 import (
 	"20hh/engine/board"
+	"20hh/engine/book"
 	"20hh/engine/search"
 
 	"bufio"
@@ -33,7 +34,14 @@ func UCILoop() {
 		case "setoption":
 			setOption(&engine, fields[2:])
 		case "ucinewgame":
-			engine = Engine{}
+			// Options carry over to the new game
+			engine = Engine{
+				chess960:      engine.chess960,
+				variant:       engine.variant,
+				ownBook:       engine.ownBook,
+				book:          engine.book,
+				bookSelection: engine.bookSelection,
+			}
 			engine.GameFromStartPos()
 			engine.ResetSearch()
 		case "position":
@@ -44,7 +52,7 @@ func UCILoop() {
 		case "stop":
 			engine.EndSearch()
 		case "printboard":
-			fmt.Println(engine.currentBoard.String())
+			printBoardCommand(&engine, fields[1:])
 		case "zobrist":
 			fmt.Printf("0x%x\n", engine.currentBoard.Hash())
 		case "quit":
@@ -57,7 +65,15 @@ func initUCI() {
 	fmt.Println("id name 20HH")
 	fmt.Println("id author Ryan Peabody")
 
-	// TODO : print options
+	fmt.Println("option name UCI_Chess960 type check default false")
+	variants := ""
+	for _, variant := range board.Variants {
+		variants += " var " + variant.String()
+	}
+	fmt.Printf("option name UCI_Variant type combo default %s%s\n", board.Standard, variants)
+	fmt.Println("option name OwnBook type check default false")
+	fmt.Println("option name BookFile type string default <empty>")
+	fmt.Println("option name BookBestMove type check default false")
 	fmt.Println("uciok")
 }
 
@@ -76,8 +92,35 @@ func setOption(engine *Engine, fields []string) {
 			}
 		}
 	}
-	optionName = optionName[:len(optionName)-1]
-	optionVal = optionVal[:len(optionVal)-1]
+	optionName = strings.TrimSuffix(optionName, " ")
+	optionVal = strings.TrimSuffix(optionVal, " ")
+
+	switch optionName {
+	case "UCI_Chess960":
+		engine.SetChess960(optionVal == "true")
+	case "UCI_Variant":
+		variant, err := board.ParseVariant(optionVal)
+		if err != nil {
+			fmt.Printf("info string %s\n", err)
+			return
+		}
+		engine.SetVariant(variant)
+	case "OwnBook":
+		engine.SetOwnBook(optionVal == "true")
+	case "BookFile":
+		if optionVal == "<empty>" {
+			optionVal = ""
+		}
+		if err := engine.LoadBook(optionVal); err != nil {
+			fmt.Printf("info string couldn't load book: %s\n", err)
+		}
+	case "BookBestMove":
+		selection := book.WeightedRandom
+		if optionVal == "true" {
+			selection = book.BestMove
+		}
+		engine.SetBookSelection(selection)
+	}
 }
 
 func positionCommand(engine *Engine, command string) {
@@ -85,9 +128,21 @@ func positionCommand(engine *Engine, command string) {
 	if strings.HasPrefix(command, "fen") {
 		command = strings.TrimPrefix(command, "fen ")
 		fields := strings.Fields(command)
-		fenString := strings.Join(fields[:6], " ")
-		engine.GameFromFENString(fenString)
-		command = strings.Join(fields[6:], " ")
+		// The FEN is everything up to the move list, since
+		// its clock fields are optional
+		fenEnd := len(fields)
+		for i, field := range fields {
+			if field == "moves" {
+				fenEnd = i
+				break
+			}
+		}
+		fenString := strings.Join(fields[:fenEnd], " ")
+		if err := engine.GameFromFENString(fenString); err != nil {
+			fmt.Printf("info string %s\n", err)
+			return
+		}
+		command = strings.Join(fields[fenEnd:], " ")
 	} else if strings.HasPrefix(command, "startpos") {
 		command = strings.TrimPrefix(command, "startpos ")
 		engine.GameFromStartPos()
@@ -103,10 +158,45 @@ func positionCommand(engine *Engine, command string) {
 	}
 }
 
+// printboard [unicode] [color] [black] [attacks|pinned|checkers]
+// The overlays mark what the side to move has to worry about
+func printBoardCommand(engine *Engine, fields []string) {
+	var opts board.RenderOptions
+	b := &engine.currentBoard
+	whoseTurn, opponent := board.White, board.Black
+	if b.BlackToMove() {
+		whoseTurn, opponent = board.Black, board.White
+	}
+	for _, field := range fields {
+		switch field {
+		case "unicode":
+			opts.Unicode = true
+		case "color":
+			opts.Color = true
+		case "black":
+			opts.FromBlack = true
+		case "attacks":
+			opts.Overlay = b.AttackMap(opponent)
+		case "pinned":
+			opts.Overlay = b.Pinned(whoseTurn)
+		case "checkers":
+			opts.Overlay = b.Checkers()
+		default:
+			fmt.Printf("info string unknown printboard option \"%s\"\n", field)
+			return
+		}
+	}
+	fmt.Println(engine.RenderBoard(opts))
+}
+
 func goCommand(engine *Engine, command string) {
 	// TODO handle movestogo, depth, movetime
 	infinite := false
 	fields := strings.Fields(command)
+	if len(fields) > 1 && fields[1] == "perft" {
+		perftCommand(engine, fields[2:])
+		return
+	}
 	timeRemaining := 60000 // 1 minute default
 	timeInc := 0
 	maxNodes := int((^uint(0)) >> 1)
@@ -139,16 +229,30 @@ func goCommand(engine *Engine, command string) {
 		infinite,
 	}
 	fmt.Printf("bestmove %s\n",
-		engine.GetBestMove(opts, printInfo),
+		engine.MoveToUCI(engine.GetBestMove(opts, engine.printInfo)),
 	)
 }
 
+// go perft <depth>, printing the nodes under each move like other engines do
+func perftCommand(engine *Engine, fields []string) {
+	if len(fields) == 0 {
+		fmt.Println("info string go perft needs a depth")
+		return
+	}
+	depth, err := strconv.Atoi(fields[0])
+	if err != nil || depth < 0 {
+		fmt.Printf("info string invalid perft depth \"%s\"\n", fields[0])
+		return
+	}
+	engine.Perft(depth, os.Stdout)
+}
+
 // Print incremental updates to UCI
-func printInfo(log search.SearchLog) {
+func (engine *Engine) printInfo(log search.SearchLog) {
 	// Format principle variation
 	pvString := ""
 	for i := 0; i < 15 && log.PV[i] != board.NullMove; i++ {
-		pvString += fmt.Sprintf(" %s", log.PV[i])
+		pvString += fmt.Sprintf(" %s", engine.MoveToUCI(log.PV[i]))
 	}
 
 	// Format score display
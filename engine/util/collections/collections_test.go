@@ -58,3 +58,24 @@ func TestArrayStack(t *testing.T) {
 		t.Errorf("Stack has data of %v", testStack.data)
 	}
 }
+
+func TestArrayStackGrows(t *testing.T) {
+	testStack := NewArrayStack[int](2)
+	for i := 9; i >= 0; i-- {
+		testStack.Push(i)
+	}
+	clone := testStack.Clone()
+	clone.Pop()
+	clone.Push(-1)
+
+	if testStack.Size() != 10 {
+		t.Errorf("Stack has size %d instead of 10", testStack.Size())
+	}
+	var out []int
+	for testStack.Size() > 0 {
+		out = append(out, testStack.Pop())
+	}
+	if !is0To9(out) {
+		t.Errorf("Stack returns %v instead of 0-9", out)
+	}
+}
@@ -1,30 +1,34 @@
 package collections
 
-// A Stack ADT with a predetermined max size
+// A Stack ADT backed by a slice that grows as needed
 type ArrayStack[T any] struct {
 	data []T
-	ptr  int
 }
 
-func NewArrayStack[T any](maxSize int) ArrayStack[T] {
+// The capacity is only a starting size, pushing past it is fine
+func NewArrayStack[T any](capacity int) ArrayStack[T] {
 	return ArrayStack[T]{
-		data: make([]T, maxSize),
-		ptr:  -1,
+		data: make([]T, 0, capacity),
 	}
 }
 
 func (s *ArrayStack[T]) Push(data T) {
-	s.ptr++
-	// Failsafe for pushing off end of array
-	if s.ptr == len(s.data) {
-		s.data = append(s.data, data)
-	} else {
-		s.data[s.ptr] = data
-	}
+	s.data = append(s.data, data)
 }
 
 func (s *ArrayStack[T]) Pop() T {
-	toReturn := s.data[s.ptr]
-	s.ptr--
+	toReturn := s.data[len(s.data)-1]
+	s.data = s.data[:len(s.data)-1]
 	return toReturn
 }
+
+func (s *ArrayStack[T]) Size() int {
+	return len(s.data)
+}
+
+// Copies the stack so pushing onto one doesn't touch the other
+func (s *ArrayStack[T]) Clone() ArrayStack[T] {
+	data := make([]T, len(s.data), cap(s.data))
+	copy(data, s.data)
+	return ArrayStack[T]{data}
+}
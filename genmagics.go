@@ -0,0 +1,63 @@
+package main
+
+import (
+	"20hh/engine/board"
+
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// gen-magics searches for new rook and bishop magic numbers, checks them
+// against the brute force attack generators and writes them out as Go
+func genMagics(args []string) error {
+	flags := flag.NewFlagSet("gen-magics", flag.ContinueOnError)
+	fixedShift := flags.Bool("fixed", false, "use the same shift on every square")
+	denser := flags.Bool("dense", false, "try for attack tables half the usual size")
+	tries := flags.Int("tries", 0, "candidate numbers per square (0 for the default)")
+	seed := flags.Uint64("seed", 0x7a3c9e41b05d2f68, "random seed")
+	output := flags.String("o", "engine/board/magictables.go", "file to write, or - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *fixedShift && *denser {
+		return fmt.Errorf("-fixed and -dense can't be used together")
+	}
+
+	board.Init()
+	current := board.CurrentMagics()
+	rookBefore, bishopBefore := current.TableSizes()
+
+	tables, err := board.FindMagics(board.MagicOptions{
+		FixedShift: *fixedShift,
+		Denser:     *denser,
+		MaxTries:   *tries,
+		Seed:       *seed,
+	})
+	if err != nil {
+		return err
+	}
+	if err := tables.Verify(); err != nil {
+		return err
+	}
+	rook, bishop := tables.TableSizes()
+	fmt.Fprintf(os.Stderr, "rook tables: %d entries (was %d)\n", rook, rookBefore)
+	fmt.Fprintf(os.Stderr, "bishop tables: %d entries (was %d)\n", bishop, bishopBefore)
+	if rookDenser, bishopDenser := tables.DenserSquares(); rookDenser+bishopDenser > 0 {
+		fmt.Fprintf(os.Stderr, "denser tables on %d rook and %d bishop squares\n",
+			rookDenser, bishopDenser)
+	}
+
+	var source bytes.Buffer
+	command := strings.Join(append([]string{"20hh gen-magics"}, args...), " ")
+	if err := tables.WriteGo(&source, command); err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(source.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, source.Bytes(), 0644)
+}
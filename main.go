@@ -2,6 +2,7 @@ package main
 
 import (
 	"20hh/engine"
+	"20hh/engine/board"
 	"strings"
 
 	"bufio"
@@ -35,11 +36,20 @@ func printBanner() {
 	fmt.Print(asciiArt)
 	fmt.Print(RESET)
 	fmt.Println()
-	fmt.Println("Enter 'uci' to start or 'quit' to exit")
+	fmt.Println("Enter 'uci' to start, 'play' to play against the engine or 'quit' to exit")
+	fmt.Println("(play black to take black, play nocolor for a terminal without colors)")
 	fmt.Println()
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen-magics" {
+		if err := genMagics(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	printBanner()
 	engine.Init()
 
@@ -55,6 +65,18 @@ func main() {
 		case "uci":
 			engine.UCILoop()
 			return
+		case "play":
+			humanColor, color := board.White, true
+			for _, option := range fields[1:] {
+				switch option {
+				case "black":
+					humanColor = board.Black
+				case "nocolor":
+					color = false
+				}
+			}
+			engine.PlayLoop(reader, humanColor, color)
+			return
 		case "quit":
 			return
 		default: